@@ -8,7 +8,6 @@ import (
 	"image"
 	"image/color"
 	"image/png"
-	"log"
 	"math"
 	"os"
 
@@ -116,25 +115,23 @@ func (c *Canvas) Ellipse(cx, cy, rx, ry float64) {
 }
 
 // Save the current canvas to a PNG file
-func (c *Canvas) SaveToPNG(filename string) {
+func (c *Canvas) SaveToPNG(filename string) error {
 	f, err := os.Create(filename)
 	if err != nil {
-		log.Println(err)
-		os.Exit(1)
+		return err
 	}
 	defer f.Close()
 	b := bufio.NewWriter(f)
 	err = png.Encode(b, c.img)
 	if err != nil {
-		log.Println(err)
-		os.Exit(1)
+		return fmt.Errorf("encoding %s: %w", filename, err)
 	}
 	err = b.Flush()
 	if err != nil {
-		log.Println(err)
-		os.Exit(1)
+		return fmt.Errorf("writing %s: %w", filename, err)
 	}
 	fmt.Printf("Wrote %s OK.\n", filename)
+	return f.Close()
 }
 
 // Return the width of the canvas
@@ -0,0 +1,599 @@
+package main
+
+import (
+  "errors"
+  "flag"
+  "fmt"
+  "math"
+  "os"
+  "strconv"
+  "strings"
+  "time"
+
+  "dis/epidemic"
+)
+
+//Exit codes used by the simulator. Every kind of bad input gets its own code so that scripts driving the
+//simulator without the prompts can tell failures apart.
+const (
+  exitPathogen = 1
+  exitRo = 2
+  exitLethality = 3
+  exitVaccine = 4
+  exitPatientZero = 5
+  exitPopulation = 6
+  exitUsage = 7
+  exitOutput = 8
+  exitNetwork = 9
+  exitInterrupted = 10
+)
+
+//SimConfig holds everything main() needs to run a simulation, whether it came from command line flags
+//or from the interactive prompts.
+type SimConfig struct {
+  pathogen epidemic.Pathogen
+  pop int
+  vaccineRate float64
+  pZero int
+  out string
+  seed int64
+  runs int
+  //workers is the number of replicates of ensembles and sweeps run at once, 0 for one per CPU
+  workers int
+  //benchmark lists the population sizes to benchmark at instead of simulating, see epidemic.Benchmark
+  benchmark []int
+  major float64
+  summary string
+  sweep []float64
+  sweepRo []float64
+  //strategy decides who is vaccinated, nil for everyone with the same probability, and sweepStrategies lists the
+  //strategies compared by a sweep
+  strategy epidemic.Strategy
+  sweepStrategies []epidemic.Strategy
+  //export lists the formats the final network is written in, and tree those of the transmission tree
+  export []string
+  tree []string
+  //latent and infectious override the periods of the pathogen when they are not nil
+  latent *epidemic.Period
+  infectious *epidemic.Period
+  //efficacy, vaccineMode and breakthrough override the vaccine of the pathogen when they are set
+  efficacy *float64
+  vaccineMode string
+  breakthrough *float64
+  //waning and vaccineWaning override the waning immunity of recovered and vaccinated people when they are not nil
+  waning *float64
+  vaccineWaning *float64
+  //maxEpochs stops every epidemic after that many timesteps, 0 for no limit. maxEpochsSet is true if -max-epochs
+  //was given, otherwise waning immunity brings in defaultWaningEpochs.
+  maxEpochs int
+  maxEpochsSet bool
+  //engine steps the epidemic through time
+  engine epidemic.Engine
+  //susceptibility and infectiousness are the distributions of those traits of the nodes, the zero Trait for the
+  //defaults
+  susceptibility epidemic.Trait
+  infectiousness epidemic.Trait
+  //networkSpec is the -network flag, parsed into network once the population is known
+  networkSpec string
+  network epidemic.Generator
+}
+
+//Scenario returns the epidemic.Scenario described by the configuration
+func (cfg SimConfig) Scenario() epidemic.Scenario {
+  return epidemic.Scenario{
+    Pathogen: cfg.pathogen,
+    Population: cfg.pop,
+    VaccineRate: cfg.vaccineRate,
+    PatientsZero: cfg.pZero,
+    Seed: cfg.seed,
+    Network: cfg.network,
+    Strategy: cfg.strategy,
+    Susceptibility: cfg.susceptibility,
+    Infectiousness: cfg.infectiousness,
+    Engine: cfg.engine,
+    MaxEpochs: cfg.maxEpochs,
+  }
+}
+
+//ParseFlags reads the command line arguments into a SimConfig. The returned bool is true when none of the
+//flags answering a prompt were given, in which case main() should fall back to the interactive prompts. The
+//remaining flags (-out, -seed, -runs, ...) may be combined with the prompts.
+func ParseFlags(args []string) (SimConfig, bool) {
+  var cfg SimConfig
+
+  fs := flag.NewFlagSet("dis", flag.ContinueOnError)
+  pathogenFlag := fs.String("pathogen", "", "path to the .PATHOGEN file to simulate")
+  popFlag := fs.String("pop", "", "population size, an integer greater than 0")
+  vacFlag := fs.String("vaccinate", "0", "percentage of the population that is vaccinated, between 0 and 100")
+  seedsFlag := fs.String("seeds", "1", "number of patients to start with the infection, 0 or more")
+  fs.StringVar(&cfg.out, "out", "", "base name of the .gif and .txt outputs (defaults to the pathogen name)")
+  fs.Int64Var(&cfg.seed, "seed", 0, "seed for the random number generator, to replay a run exactly (defaults to the current time)")
+  fs.IntVar(&cfg.runs, "runs", 1, "number of independent replicates to run; more than 1 reports ensemble statistics instead of drawing")
+  fs.IntVar(&cfg.workers, "workers", 0, "number of replicates of -runs and -sweep to run at once, 0 for one per CPU (the results are the same either way)")
+  sweepFlag := fs.String("sweep", "", "sweep the vaccination rate over start:stop:step (in percent), running -runs replicates at each point")
+  sweepRoFlag := fs.String("sweep-ro", "", "comma separated Ro values to sweep along with -sweep (defaults to the pathogen's Ro)")
+  latentFlag := fs.String("latent", "", "latent period in timesteps as dist:mean, dist being fixed, geometric or poisson (default fixed:0)")
+  infectiousFlag := fs.String("infectious", "", "infectious period in timesteps as dist:mean, dist being fixed, geometric or poisson (default fixed:1)")
+  efficacyFlag := fs.String("efficacy", "", "fraction of the vaccinated the vaccine protects, between 0 and 1 (defaults to the pathogen's, or 1)")
+  fs.StringVar(&cfg.vaccineMode, "vaccine-mode", "", "how the vaccine protects: all-or-nothing (fully, for a fraction -efficacy of the vaccinated) or leaky (everyone, against a fraction -efficacy of contacts)")
+  breakthroughFlag := fs.String("breakthrough-lethality", "", "multiplier of the mortality rate for breakthrough infections of vaccinated people (defaults to the pathogen's, or 1)")
+  waningFlag := fs.String("waning", "", "probability that a recovered person loses their immunity every timestep (defaults to the pathogen's, or 0)")
+  vaccineWaningFlag := fs.String("vaccine-waning", "", "probability that a vaccinated person loses their protection every timestep (defaults to the pathogen's, or 0)")
+  susceptibilityFlag := fs.String("susceptibility", "", "distribution of how easily people catch the disease: vulnerability (default), constant:mean, or gaussian, gamma or lognormal:mean,sd")
+  infectiousnessFlag := fs.String("infectiousness", "", "distribution of how easily people pass the disease on: constant:1 (default), vulnerability, or gaussian, gamma or lognormal:mean,sd")
+  engineFlag := fs.String("engine", "synchronous", "how the epidemic is stepped through time: synchronous (everyone acts on the state at the start of the timestep), in-place (the original update, in order of ID) or event-driven[:transmission=dist:mean] (continuous time, also writing every event to [out]_events.csv)")
+  fs.IntVar(&cfg.maxEpochs, "max-epochs", 0, "stop every epidemic after this many timesteps, 0 for no limit (default 0, or " + strconv.Itoa(defaultWaningEpochs) + " with waning immunity, which can keep a disease around forever)")
+  fs.StringVar(&cfg.summary, "summary", "both", "statistics to write: text ([out].txt), json ([out]_summary.json) or both")
+  fs.StringVar(&cfg.networkSpec, "network", "meyers", "contact network model as name or name:key=value,..., name being meyers, erdos-renyi, watts-strogatz, barabasi-albert, configuration, lattice or file")
+  strategyFlag := fs.String("strategy", "bernoulli", "vaccination strategy: bernoulli (everyone with the same probability), random, degree, acquaintance, vulnerability or betweenness[:samples=N]")
+  sweepStrategyFlag := fs.String("sweep-strategy", "", "comma separated vaccination strategies to compare along with -sweep (defaults to -strategy)")
+  exportFlag := fs.String("export", "", "comma separated formats to write the final network in: edgelist, graphml and/or gexf")
+  treeFlag := fs.String("tree", "", "comma separated formats to write the transmission tree (who infected whom) in: csv, json and/or dot")
+  benchmarkFlag := fs.String("benchmark", "", "comma separated population sizes at which to time building the network and running one epidemic, with the compact network and, up to 1000000, the original one, instead of simulating")
+  fs.Float64Var(&cfg.major, "major", 0.1, "fraction of the population that must be infected for a replicate to count as a major outbreak")
+
+  err := fs.Parse(args)
+  if err == flag.ErrHelp {
+    os.Exit(0)
+  } else if err != nil {
+    os.Exit(exitUsage)
+  }
+
+  if fs.NArg() > 0 {
+    fmt.Println("Unexpected argument:", fs.Arg(0))
+    fs.Usage()
+    os.Exit(exitUsage)
+  }
+
+  //Only the flags that answer a prompt switch off the prompts, the rest can be combined with them
+  interactive := true
+  seeded := false
+  fs.Visit(func(f *flag.Flag) {
+    if f.Name == "seed" {
+      seeded = true
+    } else if f.Name == "max-epochs" {
+      cfg.maxEpochsSet = true
+    } else if f.Name == "pathogen" || f.Name == "pop" || f.Name == "vaccinate" || f.Name == "seeds" || f.Name == "benchmark" {
+      interactive = false
+    }
+  })
+
+  if cfg.maxEpochs < 0 {
+    fmt.Println("Invalid -max-epochs. Please enter an integer of at least 0.")
+    os.Exit(exitUsage)
+  }
+
+  if cfg.runs < 1 {
+    fmt.Println("Invalid -runs. Please enter an integer greater than 0.")
+    os.Exit(exitUsage)
+  } else if cfg.workers < 0 {
+    fmt.Println("Invalid -workers. Please enter an integer of at least 0.")
+    os.Exit(exitUsage)
+  } else if cfg.major < 0.0 || cfg.major > 1.0 {
+    fmt.Println("Invalid -major. Please enter a decimal number between 0 and 1, inclusive.")
+    os.Exit(exitUsage)
+  }
+
+  if cfg.summary != "text" && cfg.summary != "json" && cfg.summary != "both" {
+    fmt.Println("Invalid -summary. Please enter text, json or both.")
+    os.Exit(exitUsage)
+  }
+
+  if *exportFlag != "" {
+    cfg.export = ParseFormatList("export", *exportFlag, epidemic.NetworkFormats)
+  }
+  if *treeFlag != "" {
+    cfg.tree = ParseFormatList("tree", *treeFlag, epidemic.TreeFormats)
+  }
+  if (*exportFlag != "" || *treeFlag != "") && (cfg.runs > 1 || *sweepFlag != "") {
+    fmt.Println("-export and -tree can only be used for a single run, without -runs or -sweep.")
+    os.Exit(exitUsage)
+  }
+
+  cfg.strategy = ParseStrategyFlag(*strategyFlag)
+
+  if *susceptibilityFlag != "" {
+    cfg.susceptibility = ParseTraitFlag("susceptibility", *susceptibilityFlag)
+  }
+  if *infectiousnessFlag != "" {
+    cfg.infectiousness = ParseTraitFlag("infectiousness", *infectiousnessFlag)
+  }
+
+  engine, err := epidemic.ParseEngine(*engineFlag)
+  if err != nil {
+    fmt.Println("Invalid -engine:", err)
+    os.Exit(exitUsage)
+  }
+  cfg.engine = engine
+
+  if *benchmarkFlag != "" {
+    cfg.benchmark = ParseSizeList(*benchmarkFlag)
+    if cfg.runs > 1 || *sweepFlag != "" || *exportFlag != "" || *treeFlag != "" {
+      fmt.Println("-benchmark cannot be used with -runs, -sweep, -export or -tree.")
+      os.Exit(exitUsage)
+    } else if cfg.strategy != nil || epidemic.EngineName(cfg.engine) != "synchronous" {
+      fmt.Println("-benchmark only works with the bernoulli -strategy and the synchronous -engine, the only ones compact networks support.")
+      os.Exit(exitUsage)
+    }
+    //The degree sequence of a configuration network fixes its population, like a network file does
+    if name := strings.SplitN(cfg.networkSpec, ":", 2)[0]; name == "configuration" || name == "config" {
+      fmt.Println("-benchmark builds networks of its own sizes and cannot be used with a configuration network, whose degree file fixes the population.")
+      os.Exit(exitUsage)
+    }
+  }
+
+  if *sweepFlag != "" {
+    cfg.sweep = ParseSweepRange(*sweepFlag)
+    cfg.sweepRo = ParseRoList(*sweepRoFlag)
+    if *sweepStrategyFlag != "" {
+      //The points of a sweep are told apart by the name of their strategy, so every strategy may only appear once
+      names := make(map[string]bool)
+      for _, field := range strings.Split(*sweepStrategyFlag, ",") {
+        strategy := ParseStrategyFlag(strings.TrimSpace(field))
+        if names[epidemic.StrategyName(strategy)] {
+          fmt.Println("Invalid -sweep-strategy. Every strategy may only be listed once, not", epidemic.StrategyName(strategy), "twice.")
+          os.Exit(exitUsage)
+        }
+        names[epidemic.StrategyName(strategy)] = true
+        cfg.sweepStrategies = append(cfg.sweepStrategies, strategy)
+      }
+    }
+  } else if *sweepRoFlag != "" || *sweepStrategyFlag != "" {
+    fmt.Println("-sweep-ro and -sweep-strategy can only be used along with -sweep.")
+    os.Exit(exitUsage)
+  }
+
+  if *latentFlag != "" {
+    cfg.latent = ParsePeriodFlag("latent", *latentFlag)
+  }
+  if *infectiousFlag != "" {
+    cfg.infectious = ParsePeriodFlag("infectious", *infectiousFlag)
+  }
+
+  if *efficacyFlag != "" {
+    cfg.efficacy = ParseVaccineFlag("efficacy", *efficacyFlag, 1)
+  }
+  if *breakthroughFlag != "" {
+    cfg.breakthrough = ParseVaccineFlag("breakthrough-lethality", *breakthroughFlag, math.Inf(1))
+  }
+  if *vaccineWaningFlag != "" {
+    cfg.vaccineWaning = ParseVaccineFlag("vaccine-waning", *vaccineWaningFlag, 1)
+  }
+  if *waningFlag != "" {
+    cfg.waning = ParseProbabilityFlag("waning", *waningFlag)
+  }
+  if cfg.vaccineMode != "" {
+    if _, err := epidemic.ParseVaccineMode(cfg.vaccineMode); err != nil {
+      fmt.Println("Invalid -vaccine-mode:", err)
+      os.Exit(exitVaccine)
+    }
+  }
+
+  //Without an explicit seed, every run is different
+  if seeded == false {
+    cfg.seed = time.Now().UTC().UnixNano()
+  }
+
+  if interactive {
+    return cfg, true
+  }
+
+  //A network file decides the population by itself, and benchmarks use their own sizes
+  popKnown := *popFlag != "" || strings.HasPrefix(cfg.networkSpec, "file:") || len(cfg.benchmark) > 0
+  if *pathogenFlag == "" || popKnown == false {
+    fmt.Println("Both -pathogen and -pop are required when running without prompts.")
+    fs.Usage()
+    os.Exit(exitUsage)
+  }
+
+  cfg.pathogen = LoadPathogen(*pathogenFlag)
+  if *popFlag != "" {
+    cfg.pop = ParsePopulation(*popFlag)
+  } else if len(cfg.benchmark) > 0 {
+    cfg.pop = cfg.benchmark[0]
+  }
+  cfg.vaccineRate = ParseVaccineRate(*vacFlag)
+  cfg.pZero = ParsePatientZero(*seedsFlag)
+
+  return cfg, false
+}
+
+//PromptConfig asks the user for every simulation parameter on standard input, filling in cfg.
+func PromptConfig(cfg *SimConfig) {
+  //Prompt the user for the .PATHOGEN file
+  fmt.Println("Please enter the full .PATHOGEN filepath for your disease, or enter \"CUSTOM\" to create your own (case-sensitive):")
+  disInput := ""
+  fmt.Scanln(&disInput)
+
+  //If the input is "CUSTOM", redirect to pathogenbuilder.go
+  if disInput == "CUSTOM" {
+    customPath, err := BuildPathogen()
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(exitPathogen)
+    }
+    disInput = customPath
+  } else {
+    disInput = "pathogens/" + disInput
+  }
+
+  //Read the pathogen from the file indicated.
+  cfg.pathogen = LoadPathogen(disInput)
+
+  //Prompt the user for the population info
+  fmt.Print("Enter Population:")
+  popString := ""
+  fmt.Scanln(&popString)
+  cfg.pop = ParsePopulation(popString)
+
+  fmt.Println("")
+  fmt.Println("")
+
+  //Prompt user for vaccine rate
+  fmt.Println("What percentage of your population is vaccinated against", cfg.pathogen.Name, "?")
+  vacString := ""
+  fmt.Scanln(&vacString)
+  cfg.vaccineRate = ParseVaccineRate(vacString)
+
+  //Prompt user for patient(s) zero information
+  fmt.Println("Specify the number of patients to start with the infection (a value of 1 corresponds to a single patient 0 and a value of 0 means no one is infected.)")
+  pZeroString := ""
+  fmt.Scanln(&pZeroString)
+  cfg.pZero = ParsePatientZero(pZeroString)
+}
+
+//LoadPathogen reads the .PATHOGEN file at filePath, exiting on any problem with it. Invalid Ro and mortality
+//rates keep their own exit codes.
+func LoadPathogen(filePath string) epidemic.Pathogen {
+  p, err := epidemic.ReadPathogenFromFile(filePath)
+  if err != nil {
+    fmt.Println(err)
+
+    var pErr *epidemic.PathogenError
+    if errors.As(err, &pErr) && pErr.Field == "Ro" {
+      os.Exit(exitRo)
+    } else if errors.As(err, &pErr) && pErr.Field == "lethality" {
+      os.Exit(exitLethality)
+    }
+    os.Exit(exitPathogen)
+  }
+
+  fmt.Println("Successfully loaded", p.Name)
+  return p
+}
+
+//ParsePopulation converts a population string into an integer greater than 0, exiting on invalid input
+func ParsePopulation(s string) int {
+  pop, err := strconv.Atoi(s)
+  if err != nil {
+    fmt.Println("Unable to Parse population input.")
+    os.Exit(exitPopulation)
+  } else if pop <= 0 {
+    fmt.Println("Invalid input. Please enter an integer greater than 0.")
+    os.Exit(exitPopulation)
+  }
+  return pop
+}
+
+//ParseVaccineRate converts a vaccination percentage string into a float64 between 0 and 100, exiting on invalid
+//input
+func ParseVaccineRate(s string) float64 {
+  vaccineRate, err := strconv.ParseFloat(s, 64)
+  if err != nil {
+    fmt.Println("Unable to Parse Vaccination Rate.")
+    os.Exit(exitVaccine)
+  } else if vaccineRate < 0.0 || vaccineRate > 100.0 || math.IsNaN(vaccineRate) {
+    fmt.Println("Invalid input. Please enter a number between 0 and 100, inclusive.")
+    os.Exit(exitVaccine)
+  }
+  return vaccineRate
+}
+
+//ParsePatientZero converts the number of initially infected patients into an integer of at least 0, exiting on
+//invalid input
+func ParsePatientZero(s string) int {
+  pZero, err := strconv.Atoi(s)
+  if err != nil {
+    fmt.Println("Unable to Parse patient(s) zero.")
+    os.Exit(exitPatientZero)
+  } else if pZero < 0 {
+    fmt.Println("Invalid input. Please enter an integer greater than or equal to 0.")
+    os.Exit(exitPatientZero)
+  }
+  return pZero
+}
+
+//defaultWaningEpochs is the number of timesteps epidemics with waning immunity are stopped after unless
+//-max-epochs says otherwise
+const defaultWaningEpochs = 1000
+
+//maxSweepPoints is the largest number of vaccination rates a sweep can have
+const maxSweepPoints = 10000
+
+//ParseSweepRange converts a start:stop:step vaccination range (in percent) into the list of rates to sweep,
+//exiting on invalid input
+func ParseSweepRange(s string) []float64 {
+  parts := strings.Split(s, ":")
+  if len(parts) != 3 {
+    fmt.Println("Invalid -sweep. Please enter it as start:stop:step, for example 0:100:10.")
+    os.Exit(exitUsage)
+  }
+
+  bounds := make([]float64, 3)
+  for i := range parts {
+    v, err := strconv.ParseFloat(parts[i], 64)
+    if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+      fmt.Println("Unable to Parse -sweep value", parts[i])
+      os.Exit(exitUsage)
+    }
+    bounds[i] = v
+  }
+
+  start, stop, step := bounds[0], bounds[1], bounds[2]
+  if start < 0.0 || stop > 100.0 || start > stop || step <= 0.0 {
+    fmt.Println("Invalid -sweep. Please use 0 <= start <= stop <= 100 and a step greater than 0.")
+    os.Exit(exitUsage)
+  }
+
+  //Count the points first so that floating point steps do not drop the last one
+  points := (stop - start) / step + 1e-9
+  if points >= maxSweepPoints {
+    fmt.Println("Invalid -sweep. The step is too small, a sweep can have at most", maxSweepPoints, "points.")
+    os.Exit(exitUsage)
+  }
+  count := int(points) + 1
+  rates := make([]float64, count)
+  for i := range rates {
+    rates[i] = start + float64(i) * step
+  }
+  return rates
+}
+
+//ParseRoList converts a comma separated list of Ro values into a slice, exiting on invalid input or an Ro listed
+//twice. An empty string gives an empty slice.
+func ParseRoList(s string) []float64 {
+  ros := make([]float64, 0)
+  if s == "" {
+    return ros
+  }
+
+  for _, part := range strings.Split(s, ",") {
+    ro, err := strconv.ParseFloat(part, 64)
+    if err != nil {
+      fmt.Println("Unable to Parse -sweep-ro value", part)
+      os.Exit(exitUsage)
+    } else if ro <= 0.0 || math.IsInf(ro, 1) || math.IsNaN(ro) {
+      fmt.Println("Invalid -sweep-ro. Please enter decimal numbers greater than 0.")
+      os.Exit(exitUsage)
+    }
+    for _, other := range ros {
+      if other == ro {
+        fmt.Println("Invalid -sweep-ro. Every Ro may only be listed once, not", ro, "twice.")
+        os.Exit(exitUsage)
+      }
+    }
+    ros = append(ros, ro)
+  }
+  return ros
+}
+
+//ParseSizeList converts the comma separated population sizes of -benchmark into a list, exiting on sizes that
+//are not integers greater than 0
+func ParseSizeList(s string) []int {
+  sizes := make([]int, 0)
+  for _, part := range strings.Split(s, ",") {
+    size, err := strconv.Atoi(strings.TrimSpace(part))
+    if err != nil || size < 1 {
+      fmt.Println("Invalid -benchmark. Please enter comma separated integers greater than 0.")
+      os.Exit(exitUsage)
+    }
+    sizes = append(sizes, size)
+  }
+  return sizes
+}
+
+//ParseFormatList parses the comma separated list of output formats given to the flag name, exiting on formats
+//that are not in known
+func ParseFormatList(name, s string, known []string) []string {
+  formats := make([]string, 0)
+  for _, field := range strings.Split(s, ",") {
+    format := strings.TrimSpace(field)
+    found := false
+    for _, f := range known {
+      if f == format {
+        found = true
+      }
+    }
+    if found == false {
+      fmt.Println("Invalid -" + name + ". Please enter " + strings.Join(known, ", ") + " or several of them, separated by commas.")
+      os.Exit(exitUsage)
+    }
+    formats = append(formats, format)
+  }
+  return formats
+}
+
+//ParseStrategyFlag reads a vaccination strategy given on the command line, exiting if it is invalid
+func ParseStrategyFlag(s string) epidemic.Strategy {
+  strategy, err := epidemic.ParseStrategy(s)
+  if err != nil {
+    fmt.Println("Invalid -strategy:", err)
+    os.Exit(exitUsage)
+  }
+  return strategy
+}
+
+//ParseVaccineFlag parses the value of a vaccine flag such as -efficacy, a decimal number between 0 and max,
+//exiting on invalid input
+func ParseVaccineFlag(name, s string, max float64) *float64 {
+  v, err := strconv.ParseFloat(s, 64)
+  if err != nil {
+    fmt.Println("Unable to Parse -" + name, s)
+    os.Exit(exitVaccine)
+  } else if v < 0.0 || v > max || math.IsNaN(v) || math.IsInf(v, 1) {
+    if math.IsInf(max, 1) {
+      fmt.Println("Invalid -" + name + ". Please enter a decimal number of at least 0.")
+    } else {
+      fmt.Println("Invalid -" + name + ". Please enter a decimal number between 0 and", max, "inclusive.")
+    }
+    os.Exit(exitVaccine)
+  }
+  return &v
+}
+
+//ParseProbabilityFlag parses the value of a flag that is a probability, between 0 and 1, exiting on invalid input
+func ParseProbabilityFlag(name, s string) *float64 {
+  v, err := strconv.ParseFloat(s, 64)
+  if err != nil || v < 0.0 || v > 1.0 || math.IsNaN(v) {
+    fmt.Println("Invalid -" + name + ". Please enter a decimal number between 0 and 1, inclusive.")
+    os.Exit(exitUsage)
+  }
+  return &v
+}
+
+//ParseTraitFlag parses the value of a trait flag such as -susceptibility, exiting on invalid input
+func ParseTraitFlag(name, s string) epidemic.Trait {
+  trait, err := epidemic.ParseTrait(s)
+  if err != nil {
+    fmt.Println("Invalid -" + name + ":", err)
+    os.Exit(exitUsage)
+  }
+  return trait
+}
+
+//ParsePeriodFlag parses the value of a period flag such as -latent, exiting on invalid input
+func ParsePeriodFlag(name, s string) *epidemic.Period {
+  period, err := epidemic.ParsePeriod(s)
+  if err != nil {
+    fmt.Println("Invalid -" + name + ":", err)
+    os.Exit(exitUsage)
+  }
+  return &period
+}
+
+//ParseNetworkFlag reads the -network flag for a population of size pop, exiting if it is invalid. Network files
+//that cannot be read get their own exit code.
+func ParseNetworkFlag(s string, pop int) epidemic.Generator {
+  g, err := epidemic.ParseGenerator(s, pop)
+  if err != nil {
+    fmt.Println("Invalid -network:", err)
+    if strings.HasPrefix(s, "file:") {
+      os.Exit(exitNetwork)
+    }
+    os.Exit(exitUsage)
+  }
+  return g
+}
+
+//HasNetworkParam returns true if the -network spec s sets the parameter key, as in "meyers:C=90"
+func HasNetworkParam(s, key string) bool {
+  if strings.Contains(s, ":") == false {
+    return false
+  }
+  for _, kv := range strings.Split(strings.SplitN(s, ":", 2)[1], ",") {
+    if strings.TrimSpace(strings.SplitN(kv, "=", 2)[0]) == key {
+      return true
+    }
+  }
+  return false
+}
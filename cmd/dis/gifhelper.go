@@ -9,15 +9,14 @@ import (
 )
 
 // Process() takes a slice of images and uses them to generate an animated GIF
-// with the name "filename.out.gif" where filename is an input parameter.
-func Process(imglist []image.Image, filename string) {
+// with the name "filename.gif" where filename is an input parameter.
+func Process(imglist []image.Image, filename string) error {
 
 	// get ready to write images to files
 	w, err := os.Create(filename + ".gif")
 
 	if err != nil {
-		fmt.Println("Sorry: couldn't create the file!")
-		os.Exit(1)
+		return err
 	}
 
 	defer w.Close()
@@ -31,7 +30,11 @@ func Process(imglist []image.Image, filename string) {
 		g.Delay[i] = 100
 	}
 
-	gif.EncodeAll(w, &g)
+	err = gif.EncodeAll(w, &g)
+	if err != nil {
+		return fmt.Errorf("encoding %s.gif: %w", filename, err)
+	}
+	return w.Close()
 }
 
 // ImageToPaletted converts an image to an image.Paletted with 256 colors.
@@ -0,0 +1,345 @@
+package main
+
+import (
+  "context"
+  "fmt"
+  "os"
+  "os/signal"
+  "math/rand"
+  "math"
+  "strconv"
+  "image"
+
+  "dis/epidemic"
+)
+
+func main() {
+  //Read the simulation parameters from the command line, or prompt for them if no flags were given
+  cfg, interactive := ParseFlags(os.Args[1:])
+  if interactive {
+    PromptConfig(&cfg)
+  }
+
+  //The -latent and -infectious flags take precedence over the pathogen's own periods
+  if cfg.latent != nil {
+    cfg.pathogen.Latent = *cfg.latent
+  }
+  if cfg.infectious != nil {
+    cfg.pathogen.Infectious = *cfg.infectious
+  }
+
+  //And so do the vaccine flags over the pathogen's vaccine
+  if cfg.efficacy != nil {
+    cfg.pathogen.Vaccine.Efficacy = *cfg.efficacy
+  }
+  if cfg.vaccineMode != "" {
+    cfg.pathogen.Vaccine.Mode = cfg.vaccineMode
+  }
+  if cfg.breakthrough != nil {
+    cfg.pathogen.Vaccine.Lethality = *cfg.breakthrough
+  }
+  if cfg.vaccineWaning != nil {
+    cfg.pathogen.Vaccine.Waning = *cfg.vaccineWaning
+  }
+  if cfg.waning != nil {
+    cfg.pathogen.Waning = *cfg.waning
+  }
+
+  //With waning immunity an epidemic may never end, and a single run would draw images until it ran out of memory
+  if cfg.maxEpochsSet == false && (cfg.pathogen.Waning > 0 || cfg.pathogen.Vaccine.Waning > 0) {
+    cfg.maxEpochs = defaultWaningEpochs
+    fmt.Println("Immunity wanes, so every epidemic stops after", cfg.maxEpochs, "timesteps unless -max-epochs says otherwise")
+  }
+
+  //Transmission times must mostly fall within the infectious period, which the pathogen only now has for good
+  if e, ok := cfg.engine.(epidemic.EventEngine); ok {
+    if err := e.CheckTransmission(cfg.pathogen); err != nil {
+      fmt.Println("Invalid -engine:", err)
+      os.Exit(exitUsage)
+    }
+  }
+
+  //The network can only be parsed once the population is known, since the default Meyers constant depends on it
+  cfg.network = ParseNetworkFlag(cfg.networkSpec, cfg.pop)
+  if c, ok := cfg.network.(epidemic.ContactNetwork); ok && len(c.Nodes) != cfg.pop {
+    fmt.Println("The network file holds", len(c.Nodes), "people, which sets the population")
+    cfg.pop = len(c.Nodes)
+  }
+
+  //Every random draw in the simulation comes from this generator, so the same seed replays the same epidemic
+  fmt.Println("Using random seed", cfg.seed)
+  r := rand.New(rand.NewSource(cfg.seed))
+
+  pop := cfg.pop
+  pathName := cfg.pathogen.Name
+  pZero := cfg.pZero
+
+  //Outputs are named after the pathogen unless -out says otherwise
+  outName := cfg.out
+  if outName == "" {
+    outName = pathName
+  }
+
+  //Benchmarks time the network and one epidemic at every size, building a new network of every size the same way
+  if len(cfg.benchmark) > 0 {
+    if _, ok := cfg.network.(epidemic.ContactNetwork); ok {
+      fmt.Println("-benchmark builds networks of its own sizes and cannot be used with a network file.")
+      os.Exit(exitUsage)
+    }
+    results := make([]epidemic.BenchmarkResult, 0)
+    for _, size := range cfg.benchmark {
+      s := cfg.Scenario()
+      s.Population = size
+      s.Network = ParseNetworkFlag(cfg.networkSpec, size)
+      if m, ok := s.Network.(epidemic.MeyersGenerator); ok && HasNetworkParam(cfg.networkSpec, "C") == false {
+        m.C = epidemic.BenchmarkMeyersC
+        s.Network = m
+      }
+      //Networks of Nodes take several gigabytes beyond a million people
+      if size <= 1000000 {
+        fmt.Println("Benchmarking", epidemic.DescribeGenerator(s.Network), "network of", size, "Nodes")
+        results = append(results, epidemic.Benchmark(s, false))
+      }
+      fmt.Println("Benchmarking compact", epidemic.DescribeGenerator(s.Network), "network of", size, "people")
+      results = append(results, epidemic.Benchmark(s, true))
+    }
+    fmt.Println("")
+    epidemic.WriteBenchmarkTable(os.Stdout, results)
+    if err := epidemic.WriteBenchmarkToFile(results, outName); err != nil {
+      fmt.Println(err)
+      os.Exit(exitOutput)
+    }
+    fmt.Println("Benchmark written to", outName + "_benchmark.csv")
+    return
+  }
+
+  fmt.Println("Creating", epidemic.DescribeGenerator(cfg.network), "network with population", pop)
+
+  //Sweeps and ensembles run many epidemics without drawing any of them, several at once. Ctrl-C stops them
+  //early, and the statistics of the replicates that finished are still written.
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+  runner := epidemic.Runner{Workers: cfg.workers, Progress: func(done, total int) {
+    fmt.Printf("\r%d of %d replicates done", done, total)
+  }}
+
+  if len(cfg.sweep) > 0 {
+    fmt.Println("Sweeping", len(cfg.sweep), "vaccination rates with", cfg.runs, "replicates each")
+    points, err := runner.Sweep(ctx, cfg.Scenario(), cfg.runs, cfg.sweep, cfg.sweepRo, cfg.sweepStrategies)
+    stop()
+    fmt.Println("")
+    if err != nil {
+      fmt.Println("Interrupted, keeping the", len(points), "points of the sweep that were complete")
+    }
+    if len(points) > 0 {
+      fmt.Println("")
+      epidemic.WriteSweepSummary(os.Stdout, points, pop, cfg.major)
+      if err := epidemic.WriteSweepToFile(points, pop, cfg.major, outName); err != nil {
+        fmt.Println(err)
+        os.Exit(exitOutput)
+      }
+      fmt.Println("Sweep written to", outName + "_sweep.csv")
+    }
+    if err != nil {
+      os.Exit(exitInterrupted)
+    }
+    return
+  }
+
+  if cfg.runs > 1 {
+    fmt.Println("Running", cfg.runs, "replicates of", pathName)
+    results, err := runner.Ensemble(ctx, cfg.Scenario(), cfg.runs)
+    stop()
+    fmt.Println("")
+    if err != nil {
+      fmt.Println("Interrupted, keeping the", len(results), "replicates that finished")
+    }
+    if len(results) > 0 {
+      if err := epidemic.WriteEnsembleToFile(results, cfg.Scenario(), cfg.major, outName); err != nil {
+        fmt.Println(err)
+        os.Exit(exitOutput)
+      }
+      fmt.Printf("Major outbreaks in %d of %d replicates. Statistics written to %s_ensemble.txt and %s_ensemble.csv\n", epidemic.CountMajor(results, pop, cfg.major), len(results), outName, outName)
+    }
+    if err != nil {
+      os.Exit(exitInterrupted)
+    }
+    return
+  }
+  stop()
+
+  //Initialize the network with the chosen generator and traits, and an empty slice of images for visualization
+  net := cfg.Scenario().BuildNetwork(r)
+
+  progression := make([]image.Image, 0)
+
+  fmt.Println("Network successfully generated!")
+
+  p1 := cfg.pathogen
+
+  cfg.Scenario().Vaccinate(r, net)
+
+  //Initialize the patient(s) zero
+  net.SeedInfection(r, pZero, p1)
+
+  //Percolation theory predicts the epidemic from the network as it starts out, with as many patients zero as
+  //were actually infected
+  predicted := cfg.Scenario()
+  predicted.PatientsZero = net.CountStatus("I")
+  pred := epidemic.ScenarioPrediction(predicted, net)
+
+  //Now draw our initial infected network to '0.png'
+  img, err := DrawNetwork(net, 10, 0)
+  if err != nil {
+    fmt.Println(err)
+    os.Exit(exitOutput)
+  }
+  progression = append(progression, img)
+
+  //The event-driven engine records every event as it goes, for [outName]_events.csv
+  engine := cfg.engine
+  events := make([]epidemic.Event, 0)
+  if e, ok := engine.(epidemic.EventEngine); ok {
+    e.Record = func(ev epidemic.Event) {
+      events = append(events, ev)
+    }
+    engine = e
+  }
+
+  //Keep infecting until the network is no longer infected, drawing every timestep. The first image that
+  //cannot be saved stops the drawing, and the error is reported once the epidemic is over.
+  ts := epidemic.RunEpidemic(r, net, p1, engine, cfg.maxEpochs, func(epoch int) {
+    if err == nil {
+      img, err = DrawNetwork(net, 10, epoch)
+      if err == nil {
+        progression = append(progression, img)
+      }
+    }
+  })
+  if err != nil {
+    fmt.Println(err)
+    os.Exit(exitOutput)
+  }
+
+  //We write a death map which maps status strings to counts from our network, then it is
+  //Passed through to WriteEpidemicToFile().
+  deathMap := epidemic.CountStatuses(net)
+
+  fmt.Println("Processing images...")
+  if err := Process(progression, outName); err != nil {
+    fmt.Println(err)
+    os.Exit(exitOutput)
+  }
+  fmt.Println("done!")
+
+  //Now write our epidemic to file, as text and/or as a JSON summary
+  if cfg.summary != "json" {
+    fmt.Println("Writing Epidemic Statistics to", outName + ".txt")
+    if err := epidemic.WriteEpidemicToFile(deathMap, cfg.Scenario(), net, ts, pred, outName); err != nil {
+      fmt.Println(err)
+      os.Exit(exitOutput)
+    }
+  }
+  if cfg.summary != "text" {
+    fmt.Println("Writing the summary to", outName + "_summary.json")
+    if err := epidemic.WriteReportToFile(epidemic.NewReport(cfg.Scenario(), net, ts, pred), outName); err != nil {
+      fmt.Println(err)
+      os.Exit(exitOutput)
+    }
+  }
+
+  //The epidemic curve is written for plotting and other tools
+  fmt.Println("Writing the epidemic curve to", outName + "_timeseries.csv and", outName + "_timeseries.json")
+  if err := epidemic.WriteTimeSeriesToFile(ts, outName); err != nil {
+    fmt.Println(err)
+    os.Exit(exitOutput)
+  }
+
+  if _, ok := engine.(epidemic.EventEngine); ok {
+    fmt.Println("Writing every event to", outName + "_events.csv")
+    if err := epidemic.WriteEventsToFile(events, net, outName); err != nil {
+      fmt.Println(err)
+      os.Exit(exitOutput)
+    }
+  }
+
+  //The network itself, in its final state, for external graph tools
+  for _, format := range cfg.export {
+    fileName, err := epidemic.WriteNetworkToFile(net, format, outName)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(exitOutput)
+    }
+    fmt.Println("Network written to", fileName)
+  }
+
+  if len(cfg.tree) > 0 {
+    tree := epidemic.BuildTransmissionTree(net)
+    for _, format := range cfg.tree {
+      fileName, err := epidemic.WriteTransmissionTreeToFile(tree, net, format, outName)
+      if err != nil {
+        fmt.Println(err)
+        os.Exit(exitOutput)
+      }
+      fmt.Println("Transmission tree written to", fileName)
+    }
+  }
+}
+
+//DrawNetwork is an adaptation of the drawing code from Cellular Automata, rewritten slightly
+//To write a network. The image is also saved to progression/[i].png
+func DrawNetwork(n epidemic.Network, cellWidth int, i int) (image.Image, error) {
+  sqrt := int(math.Sqrt(float64(len(n))))
+
+  height := (sqrt + 1) * cellWidth
+	width := sqrt * cellWidth
+	c := CreateNewCanvas(width, height)
+
+	// declare colors
+  //darkGray := MakeColor(10, 10, 10)
+	//black := MakeColor(0, 0, 0)
+	blue := MakeColor(0, 107, 225)
+	red := MakeColor(211, 10, 10)
+	//green := MakeColor(0, 255, 0)
+	yellow := MakeColor(170, 175, 8)
+	orange := MakeColor(230, 120, 20)
+	//magenta := MakeColor(255, 0, 255)
+	white := MakeColor(255, 255, 255)
+	//cyan := MakeColor(0, 255, 255)
+
+	// fill in colored squares. S and V are white, E is orange, I is yellow, D is red, and R is blue
+	for i := 0; i <= sqrt; i++ {
+		for j := 0; j < sqrt; j++ {
+      index := (i * sqrt) + j
+			if index >= len(n) {
+        c.SetFillColor(white)
+      } else if n[index].Status == "S" {
+				c.SetFillColor(white)
+			} else if n[index].Status == "E" {
+				c.SetFillColor(orange)
+			} else if n[index].Status == "I" {
+				c.SetFillColor(yellow)
+			} else if n[index].Status == "V" {
+				c.SetFillColor(white)
+			} else if n[index].Status == "R" {
+				c.SetFillColor(blue)
+			} else if n[index].Status == "D" {
+				c.SetFillColor(red)
+			} else {
+        c.SetFillColor(white)
+      }
+
+			x := j * cellWidth
+			y := i * cellWidth
+			c.ClearRect(x, y, x+cellWidth, y+cellWidth)
+			c.Fill()
+		}
+	}
+
+  st := strconv.Itoa(i)
+
+  //Save to the progression directory
+  err := c.SaveToPNG("progression/" + st + ".png")
+
+
+	return c.img, err
+}
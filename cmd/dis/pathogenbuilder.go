@@ -3,11 +3,14 @@ package main
 import(
   "fmt"
   "os"
+
+  "dis/epidemic"
 )
 
-//Writes a .PATHOGEN file specifying the name of the disease, it's base reproductive ratio, and it's mortality rate. This is
-//a private function called in main.go
-func BuildPathogen() string {
+//Writes a version 2 .PATHOGEN file specifying the name of the disease, it's base reproductive ratio, and it's mortality rate.
+//The remaining fields can be added to the file by hand afterwards. Returns the path of the new file. This is a private
+//function called in main.go
+func BuildPathogen() (string, error) {
   fmt.Print("Please create a pathogen to infect your population. First, name your pathogen (no whitespaces please): ")
 
   //Read the pathogen name from user input
@@ -43,15 +46,15 @@ func BuildPathogen() string {
   outputPath := "pathogens/" + pathName + ".PATHOGEN"
   outFile, errF := os.Create(outputPath)
   if errF != nil {
-    fmt.Println("Error writing to file")
-    os.Exit(1)
+    return "", fmt.Errorf("creating custom pathogen: %w", errF)
   }
 
   defer outFile.Close()
 
-  fmt.Fprintln(outFile, pathName)
-  fmt.Fprintln(outFile, roString)
-  fmt.Fprintln(outFile, mortString)
+  fmt.Fprintln(outFile, epidemic.PathogenHeader)
+  fmt.Fprintln(outFile, "name =", pathName)
+  fmt.Fprintln(outFile, "Ro =", roString)
+  fmt.Fprintln(outFile, "lethality =", mortString)
 
-  return outputPath
+  return outputPath, outFile.Close()
 }
@@ -0,0 +1,120 @@
+package epidemic
+
+import (
+  "fmt"
+  "io"
+  "math/rand"
+  "os"
+  "runtime"
+  "time"
+)
+
+//BenchmarkResult is how long building the network of a scenario and running one epidemic on it took, and how much
+//memory it needed, with the Network of Nodes ("nodes") or the CompactNetwork ("compact"). NetworkMB is the size of
+//the heap once the network was built and SysMB the memory the whole program had taken from the operating system by
+//the end of the epidemic, in megabytes.
+type BenchmarkResult struct {
+  Representation string
+  Population int
+  Edges int64
+  Build time.Duration
+  Epidemic time.Duration
+  Epochs int
+  AttackRate float64
+  NetworkMB float64
+  SysMB float64
+}
+
+//BenchmarkMeyersC is the constant C of the Meyers networks benchmarked when none is given. The default C of a tenth of
+//the population would make the networks denser the larger they are, with hundreds of contacts per person at
+//10,000,000, while this one keeps the mean degree of the default network of 1000 people (about 17) at every size.
+const BenchmarkMeyersC = 100.0
+
+//heapMB returns the size of the live heap in megabytes, after a garbage collection
+func heapMB() float64 {
+  runtime.GC()
+  var m runtime.MemStats
+  runtime.ReadMemStats(&m)
+  return float64(m.HeapAlloc) / (1 << 20)
+}
+
+//sysMB returns the memory the program has taken from the operating system, in megabytes
+func sysMB() float64 {
+  var m runtime.MemStats
+  runtime.ReadMemStats(&m)
+  return float64(m.Sys) / (1 << 20)
+}
+
+//Benchmark builds the network of the scenario, vaccinates and seeds it and runs one epidemic, timing the building
+//and the epidemic separately. A compact benchmark uses a CompactNetwork, which only vaccinates everyone with the
+//same probability and only steps synchronously, so the Strategy and Engine of the scenario are ignored.
+func Benchmark(s Scenario, compact bool) BenchmarkResult {
+  r := rand.New(rand.NewSource(s.Seed))
+  res := BenchmarkResult{Representation: "nodes", Population: s.Population}
+  before := heapMB()
+
+  start := time.Now()
+  if compact {
+    res.Representation = "compact"
+    c := s.BuildCompactNetwork(r)
+    res.Build = time.Since(start)
+    res.NetworkMB = heapMB() - before
+    res.Edges = c.Edges()
+
+    start = time.Now()
+    c.Vaccinate(r, s.VaccineRate / 100.0, s.Pathogen.Vaccine)
+    c.SeedInfection(r, s.PatientsZero, s.Pathogen)
+    ts := RunCompactEpidemic(r, c, s.Pathogen, s.MaxEpochs)
+    res.Epidemic = time.Since(start)
+    res.Epochs = ts.Duration()
+
+    infected := 0
+    for _, k := range c.Infections {
+      if k > 0 {
+        infected++
+      }
+    }
+    res.AttackRate = float64(infected) / float64(c.Len())
+  } else {
+    net := s.BuildNetwork(r)
+    res.Build = time.Since(start)
+    res.NetworkMB = heapMB() - before
+    for _, node := range net {
+      res.Edges += int64(len(node.Connections))
+    }
+    res.Edges /= 2
+
+    start = time.Now()
+    s.Vaccinate(r, net)
+    net.SeedInfection(r, s.PatientsZero, s.Pathogen)
+    ts := RunEpidemic(r, net, s.Pathogen, s.Engine, s.MaxEpochs, nil)
+    res.Epidemic = time.Since(start)
+    res.Epochs = ts.Duration()
+    res.AttackRate = float64(CountStatuses(net)["ever infected"]) / float64(len(net))
+  }
+  res.SysMB = sysMB()
+  return res
+}
+
+//WriteBenchmarkTable writes the results of benchmarks as a table to w
+func WriteBenchmarkTable(w io.Writer, results []BenchmarkResult) {
+  fmt.Fprintf(w, "%-8s %11s %12s %10s %10s %7s %8s %11s %9s\n", "network", "population", "edges", "build s", "epidemic s", "epochs", "attack", "network MB", "sys MB")
+  for _, res := range results {
+    fmt.Fprintf(w, "%-8s %11d %12d %10.2f %10.2f %7d %8.4f %11.1f %9.1f\n", res.Representation, res.Population, res.Edges, res.Build.Seconds(), res.Epidemic.Seconds(), res.Epochs, res.AttackRate, res.NetworkMB, res.SysMB)
+  }
+}
+
+//WriteBenchmarkToFile writes the results of benchmarks to [outName]_benchmark.csv
+func WriteBenchmarkToFile(results []BenchmarkResult, outName string) error {
+  file, err := os.Create(outName + "_benchmark.csv")
+  if err != nil {
+    return fmt.Errorf("cannot create benchmark file: %w", err)
+  }
+  defer file.Close()
+
+  fmt.Fprintln(file, "network,population,edges,build_seconds,epidemic_seconds,epochs,attack_rate,network_mb,sys_mb")
+  for _, res := range results {
+    fmt.Fprintf(file, "%s,%d,%d,%g,%g,%d,%g,%g,%g\n", res.Representation, res.Population, res.Edges, res.Build.Seconds(), res.Epidemic.Seconds(), res.Epochs, res.AttackRate, res.NetworkMB, res.SysMB)
+  }
+  return file.Close()
+}
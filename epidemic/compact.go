@@ -0,0 +1,596 @@
+package epidemic
+
+import (
+  "math"
+  "math/rand"
+)
+
+//A Network is a slice of pointers to Nodes, each with its own slice of pointers to its neighbors, which costs
+//hundreds of bytes per person and per contact. CompactNetwork stores the same population as a handful of flat
+//slices instead, in the compressed sparse row layout: the neighbors of every node are stored one after the other
+//as int32 IDs, and statuses take a single byte. That is enough for populations of tens of millions, at the price of
+//the things only Nodes keep: labels, transmission histories and thus the transmission tree and Rt.
+
+//CompactNetwork is a population stored in flat slices indexed by node ID. The neighbors of node i are
+//Neighbors[Offsets[i]:Offsets[i + 1]], Status[i] is the status letter of node i ('S', 'V', 'E', 'I', 'R' or 'D',
+//see Node) and Age[i] the index of its age group in AgeGroups.
+type CompactNetwork struct {
+  Offsets []int64
+  Neighbors []int32
+  Status []byte
+  Age []byte
+  Vaccinated []bool
+  Vulnerability []float64
+  Susceptibility []float64
+  Infectiousness []float64
+  Infections []int32
+  //timer counts down the timesteps left in the exposed or infected stage, see Node
+  timer []int32
+  //snapshot holds the statuses at the start of the timestep, kept between timesteps to save allocations
+  snapshot []byte
+}
+
+//newCompactNetwork allocates a compact network of pop unconnected nodes
+func newCompactNetwork(pop int) *CompactNetwork {
+  return &CompactNetwork{
+    Offsets: make([]int64, pop + 1),
+    Neighbors: make([]int32, 0),
+    Status: make([]byte, pop),
+    Age: make([]byte, pop),
+    Vaccinated: make([]bool, pop),
+    Vulnerability: make([]float64, pop),
+    Susceptibility: make([]float64, pop),
+    Infectiousness: make([]float64, pop),
+    Infections: make([]int32, pop),
+    timer: make([]int32, pop),
+  }
+}
+
+//NewCompactNetwork copies network n into a compact network, connections, statuses and traits included
+func NewCompactNetwork(n Network) *CompactNetwork {
+  c := newCompactNetwork(len(n))
+  total := 0
+  for _, node := range n {
+    total += len(node.Connections)
+  }
+  c.Neighbors = make([]int32, 0, total)
+
+  for i, node := range n {
+    for _, neighbor := range node.Connections {
+      c.Neighbors = append(c.Neighbors, int32(neighbor.ID))
+    }
+    c.Offsets[i + 1] = int64(len(c.Neighbors))
+    c.Status[i] = node.Status[0]
+    for a := range AgeGroups {
+      if AgeGroups[a] == node.Age {
+        c.Age[i] = byte(a)
+      }
+    }
+    c.Vaccinated[i] = node.Vaccinated
+    c.Vulnerability[i] = node.Vulnerability
+    c.Susceptibility[i] = node.Susceptibility
+    c.Infectiousness[i] = node.Infectiousness
+    c.Infections[i] = int32(node.Infections)
+    c.timer[i] = int32(node.timer)
+  }
+  return c
+}
+
+//compactGenerator is a Generator that can also connect a compact network directly, with the same random draws and
+//the same edges, in the same order, as Connect gives a Network
+type compactGenerator interface {
+  connectCompact(r *rand.Rand, c *CompactNetwork)
+}
+
+//BuildCompactNetwork builds the contact network of the scenario as a compact network and draws the traits of its
+//nodes, with the same random draws as Scenario.BuildNetwork: a compact copy of the Network it builds from the same
+//generator state would be identical. Every generator but a file connects the compact network directly, without
+//ever holding Nodes. A network read from a file is already held in memory, so it is built and copied (see
+//NewCompactNetwork).
+func (s Scenario) BuildCompactNetwork(r *rand.Rand) *CompactNetwork {
+  g, ok := s.Generator().(compactGenerator)
+  if ok == false {
+    return NewCompactNetwork(s.BuildNetwork(r))
+  }
+
+  c := newCompactNetwork(s.Population)
+
+  //The same draws as InitializeNetwork
+  for i := range c.Status {
+    c.Vulnerability[i] = GaussianVuln(r)
+    age := DrawAgeGroup(r)
+    for a := range AgeGroups {
+      if AgeGroups[a] == age {
+        c.Age[i] = byte(a)
+      }
+    }
+    c.Status[i] = 'S'
+  }
+
+  g.connectCompact(r, c)
+
+  susceptibility, infectiousness := s.Traits()
+  for i := range c.Status {
+    c.Susceptibility[i] = susceptibility.draw(r, c.Vulnerability[i])
+    c.Infectiousness[i] = infectiousness.draw(r, c.Vulnerability[i])
+  }
+  return c
+}
+
+//groupEdges sets Offsets for the undirected edges listed in pairs, edge k joining nodes edges[2k] and
+//edges[2k + 1], and returns the index k of every edge of node i in slots Offsets[i] to Offsets[i + 1], in order.
+//A self loop is listed twice for its node.
+func (c *CompactNetwork) groupEdges(edges []int32) []int32 {
+  pop := len(c.Status)
+  for i := range c.Offsets {
+    c.Offsets[i] = 0
+  }
+  for _, v := range edges {
+    c.Offsets[v + 1]++
+  }
+  for i := 0; i < pop; i++ {
+    c.Offsets[i + 1] += c.Offsets[i]
+  }
+
+  //Offsets[i] serves as the next free slot of node i, ending up where node i + 1 starts, then everything moves back
+  slots := make([]int32, len(edges))
+  for k := 0; 2 * k + 1 < len(edges); k++ {
+    a, b := edges[2 * k], edges[2 * k + 1]
+    slots[c.Offsets[a]] = int32(k)
+    c.Offsets[a]++
+    slots[c.Offsets[b]] = int32(k)
+    c.Offsets[b]++
+  }
+  for i := pop; i > 0; i-- {
+    c.Offsets[i] = c.Offsets[i - 1]
+  }
+  c.Offsets[0] = 0
+  return slots
+}
+
+//otherEnd returns the end of edge k of the list edges (see groupEdges) that is not node i. The sum of both ends
+//may wrap around, but the difference is right all the same.
+func otherEnd(edges []int32, k int32, i int) int32 {
+  return edges[2 * k] + edges[2 * k + 1] - int32(i)
+}
+
+//setEdges connects the network with the undirected edges listed in pairs, edge k joining nodes edges[2k] and
+//edges[2k + 1]. The neighbors of every node are stored in the order of the edges, as ConnectUndirected would add
+//them.
+func (c *CompactNetwork) setEdges(edges []int32) {
+  c.Neighbors = c.groupEdges(edges)
+  for i := range c.Status {
+    for s := c.Offsets[i]; s < c.Offsets[i + 1]; s++ {
+      c.Neighbors[s] = otherEnd(edges, c.Neighbors[s], i)
+    }
+  }
+}
+
+//addEdges adds the undirected edges listed in pairs after the neighbors every node already has, in order. The
+//neighbor lists are moved up in place, so no more memory is needed if Neighbors has the capacity.
+func (c *CompactNetwork) addEdges(edges []int32) {
+  if len(edges) == 0 {
+    return
+  }
+  pop := len(c.Status)
+
+  //extra[i] is the number of neighbors added to the nodes before node i
+  extra := make([]int64, pop + 1)
+  for _, v := range edges {
+    extra[v + 1]++
+  }
+  for i := 0; i < pop; i++ {
+    extra[i + 1] += extra[i]
+  }
+
+  neighbors := append(c.Neighbors, make([]int32, len(edges))...)
+  //The last node moves furthest, so moving the nodes from the last one down never overwrites a list still to move
+  for i := pop - 1; i >= 0; i-- {
+    copy(neighbors[c.Offsets[i] + extra[i]:], neighbors[c.Offsets[i]:c.Offsets[i + 1]])
+  }
+  //Then extra[i] becomes the next free slot of node i, right after its moved list
+  for i := 0; i < pop; i++ {
+    free := c.Offsets[i + 1] + extra[i]
+    c.Offsets[i] += extra[i]
+    extra[i] = free
+  }
+  c.Offsets[pop] += extra[pop]
+
+  for k := 0; 2 * k + 1 < len(edges); k++ {
+    a, b := edges[2 * k], edges[2 * k + 1]
+    neighbors[extra[a]] = b
+    extra[a]++
+    neighbors[extra[b]] = a
+    extra[b]++
+  }
+  c.Neighbors = neighbors
+}
+
+//connectStubs pairs up stubs at random so that node i gets degree degrees[i], with the same draws and the same
+//edges, in the same order, as connectStubs. The first round pairs up nearly all the stubs, so rather than
+//remembering every edge in a set it lists the pairs of every node in order and keeps the first of every repeated
+//pair, which takes constant time and memory per stub. The few stubs left over for the next rounds are checked
+//against an edgeSet as in connectStubs, holding only the edges of the nodes they belong to.
+func (c *CompactNetwork) connectStubs(r *rand.Rand, degrees []int32) {
+  total := int64(0)
+  for _, d := range degrees {
+    total += int64(d)
+  }
+  stubs := make([]int32, 0, total)
+  for i, d := range degrees {
+    for k := int32(0); k < d; k++ {
+      stubs = append(stubs, int32(i))
+    }
+  }
+  if len(stubs) < 2 {
+    return
+  }
+
+  r.Shuffle(len(stubs), func(i, j int) {
+    stubs[i], stubs[j] = stubs[j], stubs[i]
+  })
+  pairs := stubs[:len(stubs) - len(stubs) % 2]
+
+  //A pair is put back if it is a self loop or an earlier pair already joined the same nodes. Both nodes of a pair
+  //list the pairs joining them in the same order, so they agree on which one is first.
+  slots := c.groupEdges(pairs)
+  rejected := make([]bool, len(pairs) / 2)
+  mark := make([]int32, len(c.Status))
+  for i := range mark {
+    mark[i] = -1
+  }
+  for i := range c.Status {
+    for s := c.Offsets[i]; s < c.Offsets[i + 1]; s++ {
+      j := otherEnd(pairs, slots[s], i)
+      if int(j) == i || mark[j] == int32(i) {
+        rejected[slots[s]] = true
+      } else {
+        mark[j] = int32(i)
+      }
+    }
+  }
+
+  leftover := make([]int32, 0)
+  for k := range rejected {
+    if rejected[k] {
+      leftover = append(leftover, pairs[2 * k], pairs[2 * k + 1])
+    }
+  }
+
+  //Keep the neighbors of the pairs that were joined, in place. The slots left at the end make room for the edges
+  //of the next rounds.
+  kept := int64(0)
+  start := int64(0)
+  for i := range c.Status {
+    end := c.Offsets[i + 1]
+    for s := start; s < end; s++ {
+      if rejected[slots[s]] == false {
+        slots[kept] = otherEnd(pairs, slots[s], i)
+        kept++
+      }
+    }
+    start = end
+    c.Offsets[i + 1] = kept
+  }
+  c.Neighbors = slots[:kept]
+  if len(leftover) == len(pairs) {
+    return
+  }
+
+  //The next rounds, as in connectStubs. Only nodes with leftover stubs can be joined, so only their edges need to
+  //be in the set to reject repeats. mark is free again, and now marks the nodes whose edges are in the set.
+  stubs = leftover
+  seen := make(edgeSet)
+  for _, a := range stubs {
+    if mark[a] != -2 {
+      mark[a] = -2
+      for _, b := range c.Neighbors[c.Offsets[a]:c.Offsets[a + 1]] {
+        seen.add(int(a), int(b))
+      }
+    }
+  }
+  later := make([]int32, 0)
+  for len(stubs) > 1 {
+    r.Shuffle(len(stubs), func(i, j int) {
+      stubs[i], stubs[j] = stubs[j], stubs[i]
+    })
+
+    leftover = make([]int32, 0)
+    for i := 0; i + 1 < len(stubs); i += 2 {
+      a, b := stubs[i], stubs[i + 1]
+      if seen.add(int(a), int(b)) {
+        later = append(later, a, b)
+      } else {
+        leftover = append(leftover, a, b)
+      }
+    }
+
+    if len(leftover) == len(stubs) - len(stubs) % 2 {
+      break
+    }
+    stubs = leftover
+  }
+  c.addEdges(later)
+}
+
+func (g MeyersGenerator) connectCompact(r *rand.Rand, c *CompactNetwork) {
+  pop := c.Len()
+  if g.Directed {
+    c.Offsets, c.Neighbors = meyersDirected(r, pop, g.Alpha, g.Kappa, g.C)
+    return
+  }
+
+  //The same draws as ConnectNetwork
+  degrees := make([]int32, pop)
+  for i := range degrees {
+    d := PowerLaw(r, g.Alpha, g.Kappa, g.C)
+    if d > pop - 1 {
+      d = pop - 1
+    }
+    degrees[i] = int32(d)
+  }
+  c.connectStubs(r, degrees)
+}
+
+func (g ConfigurationGenerator) connectCompact(r *rand.Rand, c *CompactNetwork) {
+  degrees := make([]int32, c.Len())
+  for i := range degrees {
+    degrees[i] = int32(g.Degrees[i])
+  }
+  c.connectStubs(r, degrees)
+}
+
+func (g ErdosRenyiGenerator) connectCompact(r *rand.Rand, c *CompactNetwork) {
+  c.setEdges(g.edges(r, c.Len()))
+}
+
+func (g WattsStrogatzGenerator) connectCompact(r *rand.Rand, c *CompactNetwork) {
+  c.setEdges(g.edges(r, c.Len()))
+}
+
+func (g BarabasiAlbertGenerator) connectCompact(r *rand.Rand, c *CompactNetwork) {
+  c.setEdges(g.edges(r, c.Len()))
+}
+
+func (g LatticeGenerator) connectCompact(r *rand.Rand, c *CompactNetwork) {
+  c.setEdges(g.edges(c.Len()))
+}
+
+//Len returns the number of nodes of the network
+func (c *CompactNetwork) Len() int {
+  return len(c.Status)
+}
+
+//Degree returns the number of neighbors of node i
+func (c *CompactNetwork) Degree(i int) int {
+  return int(c.Offsets[i + 1] - c.Offsets[i])
+}
+
+//Edges returns the number of undirected edges of the network, half the number of connections of a directed one
+func (c *CompactNetwork) Edges() int64 {
+  return int64(len(c.Neighbors)) / 2
+}
+
+//MeanDegree returns the mean degree of the network, see Network.MeanDegree
+func (c *CompactNetwork) MeanDegree() float64 {
+  return float64(len(c.Neighbors)) / float64(c.Len())
+}
+
+//MeanSquaredDegree returns the mean squared degree of the network, see Network.MeanSquaredDegree
+func (c *CompactNetwork) MeanSquaredDegree() float64 {
+  k2 := 0
+  for i := range c.Status {
+    deg := c.Degree(i)
+    k2 += deg * deg
+  }
+  return float64(k2) / float64(c.Len())
+}
+
+//Transmissibility returns the transmissibility of a pathogen with the given Ro in the network, see Transmissibility
+func (c *CompactNetwork) Transmissibility(Ro float64) float64 {
+  k := c.MeanDegree()
+  k2 := c.MeanSquaredDegree()
+  return (Ro / k2) * (k - 1.0)
+}
+
+//CountStatus returns the number of nodes with the given status letter
+func (c *CompactNetwork) CountStatus(status byte) int {
+  count := 0
+  for _, s := range c.Status {
+    if s == status {
+      count++
+    }
+  }
+  return count
+}
+
+//IsInfected returns true if any node is exposed or infected
+func (c *CompactNetwork) IsInfected() bool {
+  for _, s := range c.Status {
+    if s == 'E' || s == 'I' {
+      return true
+    }
+  }
+  return false
+}
+
+//Vaccinate vaccinates every node with probability rate, like Network.Vaccinate, then decides who the vaccine v
+//works for like Vaccine.Protect. The targeted strategies need a Network.
+func (c *CompactNetwork) Vaccinate(r *rand.Rand, rate float64, v Vaccine) {
+  for i := range c.Status {
+    if r.Float64() <= rate && c.Status[i] == 'S' {
+      c.Status[i] = 'V'
+      c.Vaccinated[i] = true
+    }
+  }
+
+  if v.Mode != AllOrNothing || v.Perfect() {
+    return
+  }
+  for i := range c.Status {
+    if c.Status[i] == 'V' && r.Float64() >= v.Efficacy {
+      c.Status[i] = 'S'
+    }
+  }
+}
+
+//SeedInfection makes pZero randomly chosen susceptible nodes infectious with pathogen p, like
+//Network.SeedInfection
+func (c *CompactNetwork) SeedInfection(r *rand.Rand, pZero int, p Pathogen) {
+  for i := range c.Status {
+    if c.Status[i] == 'I' && c.Infections[i] == 0 {
+      c.makeInfectious(r, p, i)
+      c.Infections[i]++
+    }
+  }
+
+  susceptible := c.CountStatus('S')
+  for k := 0; k < pZero && susceptible > 0; k++ {
+    i := r.Intn(c.Len())
+    for c.Status[i] != 'S' {
+      i = r.Intn(c.Len())
+    }
+    c.makeInfectious(r, p, i)
+    c.Infections[i]++
+    susceptible--
+  }
+}
+
+//makeInfectious moves node i into the infectious stage, see Pathogen.MakeInfectious
+func (c *CompactNetwork) makeInfectious(r *rand.Rand, p Pathogen, i int) {
+  c.Status[i] = 'I'
+  c.timer[i] = int32(p.Infectious.Draw(r))
+  if c.timer[i] < 1 {
+    c.timer[i] = 1
+  }
+}
+
+//infect infects node i, see Pathogen.Infect
+func (c *CompactNetwork) infect(r *rand.Rand, p Pathogen, i int) {
+  c.Infections[i]++
+  latent := p.Latent.Draw(r)
+  if latent > 0 {
+    c.Status[i] = 'E'
+    c.timer[i] = int32(latent)
+  } else {
+    c.makeInfectious(r, p, i)
+  }
+}
+
+//protection returns how much of the probability of infection a contact keeps for node i, see
+//Vaccine.Susceptibility
+func (c *CompactNetwork) protection(v Vaccine, i int) float64 {
+  if c.Status[i] == 'S' {
+    return 1
+  } else if c.Status[i] == 'V' && v.Mode == Leaky {
+    return 1 - v.Efficacy
+  }
+  return 0
+}
+
+//lethalityFor returns the probability of death of node i, see Pathogen.LethalityFor
+func (c *CompactNetwork) lethalityFor(p Pathogen, i int) float64 {
+  lethality := p.Lethality * c.Vulnerability[i]
+  if multiplier, ok := p.AgeLethality[AgeGroups[c.Age[i]]]; ok {
+    lethality *= multiplier
+  }
+  if c.Vaccinated[i] {
+    lethality *= p.Vaccine.Lethality
+  }
+  return lethality
+}
+
+//InfectOnce runs one synchronous timestep of infection, exactly as InfectOnce does for a Network: a compact copy
+//of a network (see NewCompactNetwork) stepped with the same generator goes through the same epidemic.
+func (c *CompactNetwork) InfectOnce(r *rand.Rand, p Pathogen) {
+  transmitRate := c.Transmissibility(p.Ro)
+  if p.Infectious.Mean > 1.0 && transmitRate < 1.0 {
+    transmitRate = 1.0 - math.Pow(1.0 - transmitRate, 1.0 / p.Infectious.Mean)
+  }
+
+  c.snapshot = append(c.snapshot[:0], c.Status...)
+  snapshot := c.snapshot
+  for i := range c.Status {
+    status := snapshot[i]
+
+    if status == 'R' && p.Waning > 0.0 {
+      if r.Float64() < p.Waning {
+        c.Status[i] = 'S'
+      }
+    } else if status == 'V' && p.Vaccine.Waning > 0.0 {
+      if r.Float64() < p.Vaccine.Waning {
+        c.Status[i] = 'S'
+      }
+    } else if status == 'E' {
+      c.timer[i]--
+      if c.timer[i] <= 0 {
+        c.makeInfectious(r, p, i)
+      }
+    } else if status == 'I' {
+      for _, j := range c.Neighbors[c.Offsets[i]:c.Offsets[i + 1]] {
+        infectChance := r.Float64()
+        protection := c.protection(p.Vaccine, int(j))
+        if snapshot[j] != c.Status[j] {
+          protection = 0
+        }
+        if protection > 0 && infectChance <= transmitRate * c.Infectiousness[i] * c.Susceptibility[j] * protection {
+          c.infect(r, p, int(j))
+        }
+      }
+
+      c.timer[i]--
+      if c.timer[i] <= 0 {
+        if r.Float64() <= c.lethalityFor(p, i) {
+          c.Status[i] = 'D'
+        } else {
+          c.Status[i] = 'R'
+        }
+      }
+    }
+  }
+}
+
+//RecordEpoch counts the statuses of the network at the given timestep, see RecordEpoch
+func (c *CompactNetwork) RecordEpoch(epoch int, prev *Epoch) Epoch {
+  e := Epoch{Epoch: epoch}
+  for i, s := range c.Status {
+    switch s {
+    case 'S':
+      e.Susceptible++
+    case 'V':
+      e.Vaccinated++
+    case 'E':
+      e.Exposed++
+    case 'I':
+      e.Infected++
+    case 'R':
+      e.Recovered++
+    case 'D':
+      e.Dead++
+    }
+    e.TotalInfections += int(c.Infections[i])
+  }
+
+  e.NewInfections = e.TotalInfections
+  e.NewDeaths = e.Dead
+  if prev != nil {
+    e.NewInfections -= prev.TotalInfections
+    e.NewDeaths -= prev.Dead
+  }
+  return e
+}
+
+//RunCompactEpidemic keeps infecting the compact network until it is no longer infected, or for at most maxEpochs
+//timesteps if that is more than 0, and returns the epidemic curve like RunEpidemic. Compact networks keep no
+//transmission histories, so Rt is left out.
+func RunCompactEpidemic(r *rand.Rand, c *CompactNetwork, p Pathogen, maxEpochs int) TimeSeries {
+  ts := TimeSeries{c.RecordEpoch(0, nil)}
+  for epoch := 1; true; epoch++ {
+    c.InfectOnce(r, p)
+    ts = append(ts, c.RecordEpoch(epoch, &ts[len(ts) - 1]))
+    if c.IsInfected() == false || (maxEpochs > 0 && epoch >= maxEpochs) {
+      break
+    }
+  }
+  return ts
+}
@@ -0,0 +1,151 @@
+package epidemic
+
+import (
+  "math/rand"
+  "reflect"
+  "runtime"
+  "strconv"
+  "testing"
+)
+
+//compactTestSpecs lists a network of every generator that can be built directly in compact form
+var compactTestSpecs = []string{"meyers", "meyers:directed=true", "erdos-renyi:k=6", "watts-strogatz:k=6,beta=0.2",
+  "barabasi-albert:m=3", "lattice"}
+
+//compactTestScenario returns a scenario of pop people in the network described by spec, with 30 percent of them
+//vaccinated by a vaccine that protects most of them
+func compactTestScenario(t testing.TB, spec string, pop int) Scenario {
+  g, err := ParseGenerator(spec, pop)
+  if err != nil {
+    t.Fatal(err)
+  }
+  p := NewPathogen("test", 3, 0.05)
+  p.Infectious = Period{"geometric", 3}
+  p.Vaccine = Vaccine{Efficacy: 0.8, Mode: AllOrNothing, Lethality: 1}
+  return Scenario{Pathogen: p, Population: pop, VaccineRate: 30, PatientsZero: 5, Network: g}
+}
+
+//TestBuildCompactNetwork checks that building a compact network gives exactly the compact copy of the Network built
+//from the same seed
+func TestBuildCompactNetwork(t *testing.T) {
+  for _, spec := range compactTestSpecs {
+    s := compactTestScenario(t, spec, 2000)
+    for seed := int64(1); seed <= 3; seed++ {
+      want := NewCompactNetwork(s.BuildNetwork(rand.New(rand.NewSource(seed))))
+      got := s.BuildCompactNetwork(rand.New(rand.NewSource(seed)))
+      if reflect.DeepEqual(got, want) == false {
+        t.Errorf("%s, seed %d: compact network differs from the copy of the Network", spec, seed)
+      }
+    }
+  }
+
+  //A heavy tailed degree sequence leaves stubs over for several rounds of pairing
+  degrees := make([]int, 2000)
+  r := rand.New(rand.NewSource(5))
+  for i := range degrees {
+    degrees[i] = PowerLaw(r, MeyersAlpha, MeyersKappa, 200)
+  }
+  s := Scenario{Population: len(degrees), Network: ConfigurationGenerator{degrees}}
+  want := NewCompactNetwork(s.BuildNetwork(rand.New(rand.NewSource(1))))
+  got := s.BuildCompactNetwork(rand.New(rand.NewSource(1)))
+  if reflect.DeepEqual(got, want) == false {
+    t.Errorf("configuration: compact network differs from the copy of the Network")
+  }
+}
+
+//TestCompactCopyEpidemic checks that a compact copy of a Network goes through the same epidemic as the Network
+//itself, given the same random draws
+func TestCompactCopyEpidemic(t *testing.T) {
+  for _, spec := range compactTestSpecs {
+    s := compactTestScenario(t, spec, 2000)
+    net := s.BuildNetwork(rand.New(rand.NewSource(7)))
+    c := NewCompactNetwork(net)
+
+    r := rand.New(rand.NewSource(11))
+    net.Vaccinate(r, s.VaccineRate / 100.0)
+    s.Pathogen.Vaccine.Protect(r, net)
+    net.SeedInfection(r, s.PatientsZero, s.Pathogen)
+    want := RunEpidemic(r, net, s.Pathogen, SynchronousEngine{}, 0, nil)
+
+    r = rand.New(rand.NewSource(11))
+    c.Vaccinate(r, s.VaccineRate / 100.0, s.Pathogen.Vaccine)
+    c.SeedInfection(r, s.PatientsZero, s.Pathogen)
+    got := RunCompactEpidemic(r, c, s.Pathogen, 0)
+
+    //Only Nodes keep the transmission histories Rt comes from
+    for i := range want {
+      want[i].Rt = nil
+    }
+    if reflect.DeepEqual(got, want) == false {
+      t.Errorf("%s: compact epidemic lasted %d timesteps and infected %d, the Network one %d and %d", spec,
+        got.Duration(), got[len(got) - 1].TotalInfections, want.Duration(), want[len(want) - 1].TotalInfections)
+    }
+    for i := range net {
+      if c.Status[i] != net[i].Status[0] || int(c.Infections[i]) != net[i].Infections {
+        t.Errorf("%s: node %d ended up %c after %d infections, not %s after %d", spec, i, c.Status[i],
+          c.Infections[i], net[i].Status, net[i].Infections)
+        break
+      }
+    }
+  }
+}
+
+//benchmarkSizes are the populations the compact benchmarks run at. Ten million people take a minute and a
+//gigabyte, so they are left out of -short runs.
+func benchmarkSizes() []int {
+  if testing.Short() {
+    return []int{100000, 1000000}
+  }
+  return []int{100000, 1000000, 10000000}
+}
+
+//benchmarkScenario is the scenario of the benchmarks: the Meyers network of the -benchmark flag, with a fixed C so
+//that it is equally dense at every size (see BenchmarkMeyersC)
+func benchmarkScenario(b *testing.B, pop int) Scenario {
+  s := compactTestScenario(b, "meyers", pop)
+  m := s.Network.(MeyersGenerator)
+  m.C = BenchmarkMeyersC
+  s.Network = m
+  s.Pathogen = NewPathogen("flu", 2.5, 0.01)
+  s.VaccineRate = 0
+  s.PatientsZero = 20
+  s.Seed = 1
+  return s
+}
+
+func BenchmarkCompactBuild(b *testing.B) {
+  for _, pop := range benchmarkSizes() {
+    b.Run(strconv.Itoa(pop), func(b *testing.B) {
+      s := benchmarkScenario(b, pop)
+      mb := 0.0
+      b.StopTimer()
+      for i := 0; i < b.N; i++ {
+        before := heapMB()
+        b.StartTimer()
+        c := s.BuildCompactNetwork(rand.New(rand.NewSource(s.Seed)))
+        b.StopTimer()
+        mb = heapMB() - before
+        runtime.KeepAlive(c)
+      }
+      b.ReportMetric(mb, "MB")
+    })
+  }
+}
+
+func BenchmarkCompactEpidemic(b *testing.B) {
+  for _, pop := range benchmarkSizes() {
+    b.Run(strconv.Itoa(pop), func(b *testing.B) {
+      s := benchmarkScenario(b, pop)
+      b.ResetTimer()
+      for i := 0; i < b.N; i++ {
+        b.StopTimer()
+        r := rand.New(rand.NewSource(s.Seed))
+        c := s.BuildCompactNetwork(r)
+        b.StartTimer()
+
+        c.SeedInfection(r, s.PatientsZero, s.Pathogen)
+        RunCompactEpidemic(r, c, s.Pathogen, 0)
+      }
+    })
+  }
+}
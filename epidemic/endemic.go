@@ -0,0 +1,89 @@
+package epidemic
+
+import (
+  "math"
+)
+
+//With waning immunity (see Pathogen.Waning and Vaccine.Waning) recovered and vaccinated people become susceptible
+//again, so a pathogen can keep circulating instead of burning out: an endemic disease, often in recurring waves.
+//EndemicStats describes the state such an epidemic settles into, and the waves on the way there.
+
+//EndemicStats summarizes the long run behaviour of an epidemic. The equilibrium values are averaged over the last
+//Window timesteps, the second half of the run, and are fractions of the people alive at each timestep.
+type EndemicStats struct {
+  //Persisted is true if the pathogen was still circulating at the end of the run, which was then stopped by the
+  //maximum number of timesteps
+  Persisted bool `json:"persisted"`
+  Window int `json:"window"`
+  //MeanPrevalence is the fraction of people exposed or infected, MeanIncidence the fraction newly infected every
+  //timestep and MeanSusceptible the fraction susceptible
+  MeanPrevalence float64 `json:"mean_prevalence"`
+  MeanIncidence float64 `json:"mean_incidence"`
+  MeanSusceptible float64 `json:"mean_susceptible"`
+  //WavePeaks are the timesteps at which every wave of the epidemic peaked (see Waves), and MeanInterval the mean
+  //number of timesteps between the peaks, null with fewer than two waves
+  WavePeaks []int `json:"wave_peaks"`
+  MeanInterval *float64 `json:"mean_interval"`
+}
+
+//prevalence returns the number of people exposed or infected at the end of a timestep
+func (e Epoch) prevalence() int {
+  return e.Exposed + e.Infected
+}
+
+//alive returns the number of people alive at the end of a timestep
+func (e Epoch) alive() int {
+  return e.Susceptible + e.Vaccinated + e.Exposed + e.Infected + e.Recovered
+}
+
+//NewEndemicStats summarizes the long run behaviour of the epidemic curve ts
+func NewEndemicStats(ts TimeSeries) EndemicStats {
+  stats := EndemicStats{Persisted: ts[len(ts) - 1].prevalence() > 0, WavePeaks: ts.Waves()}
+
+  //The first half of the run is left out as the approach to the equilibrium
+  window := ts[len(ts) / 2:]
+  stats.Window = len(window)
+  for _, e := range window {
+    if e.alive() == 0 {
+      continue
+    }
+    alive := float64(e.alive())
+    stats.MeanPrevalence += float64(e.prevalence()) / alive / float64(len(window))
+    stats.MeanIncidence += float64(e.NewInfections) / alive / float64(len(window))
+    stats.MeanSusceptible += float64(e.Susceptible) / alive / float64(len(window))
+  }
+
+  if len(stats.WavePeaks) > 1 {
+    stats.MeanInterval = jsonFloat(float64(stats.WavePeaks[len(stats.WavePeaks) - 1] - stats.WavePeaks[0]) / float64(len(stats.WavePeaks) - 1))
+  }
+  return stats
+}
+
+//Waves returns the timestep at which every wave of the epidemic curve peaked. A wave starts when the number of
+//people exposed or infected climbs to half again its mean over the second half of the run (and to at least 1), and
+//ends once it falls back to that mean, so that the noise around an endemic equilibrium is not counted as waves. An
+//epidemic that burns out has a single wave.
+func (ts TimeSeries) Waves() []int {
+  mean := 0.0
+  window := ts[len(ts) / 2:]
+  for _, e := range window {
+    mean += float64(e.prevalence()) / float64(len(window))
+  }
+  high := math.Max(1.5 * mean, 1)
+
+  peaks := make([]int, 0)
+  inWave := false
+  for _, e := range ts {
+    prevalence := float64(e.prevalence())
+    if inWave == false && prevalence >= high {
+      inWave = true
+      peaks = append(peaks, e.Epoch)
+    } else if inWave && prevalence <= mean {
+      inWave = false
+    }
+    if inWave && e.prevalence() > ts[peaks[len(peaks) - 1]].prevalence() {
+      peaks[len(peaks) - 1] = e.Epoch
+    }
+  }
+  return peaks
+}
@@ -0,0 +1,85 @@
+package epidemic
+
+import (
+  "fmt"
+  "math/rand"
+  "strings"
+)
+
+//Engine steps an epidemic through time, one timestep at a time (see RunEpidemic). The discrete engines update every
+//node once per timestep, while the EventEngine carries out the events of the timestep in continuous time.
+type Engine interface {
+  //Step advances the epidemic in network n by the timestep numbered epoch
+  Step(r *rand.Rand, n Network, p Pathogen, epoch int)
+  Name() string
+}
+
+//SynchronousEngine updates every node from the state of the network at the start of the timestep, see InfectOnce
+type SynchronousEngine struct{}
+
+//InPlaceEngine updates every node in order of ID as it goes, as the simulator originally did, see
+//InfectOnceInPlace
+type InPlaceEngine struct{}
+
+//Engines lists the names ParseEngine accepts
+var Engines = []string{"synchronous", "in-place", "event-driven"}
+
+//ParseEngine reads an engine by name, one of Engines. The event-driven engine takes a distribution of transmission
+//times as event-driven:transmission=dist:mean, read with ParsePeriod (default exponential contacts, see
+//EventEngine).
+func ParseEngine(spec string) (Engine, error) {
+  name := spec
+  params := ""
+  if strings.Contains(spec, ":") {
+    parts := strings.SplitN(spec, ":", 2)
+    name, params = parts[0], parts[1]
+  }
+
+  if name == "event-driven" && params != "" {
+    pair := strings.SplitN(params, "=", 2)
+    if len(pair) != 2 || strings.TrimSpace(pair[0]) != "transmission" {
+      return nil, fmt.Errorf("unknown parameter %q for engine event-driven, expected transmission=dist:mean", params)
+    }
+    delay, err := ParsePeriod(strings.TrimSpace(pair[1]))
+    if err != nil {
+      return nil, fmt.Errorf("event-driven transmission: %w", err)
+    }
+    return EventEngine{Transmission: delay}, nil
+  } else if params != "" {
+    return nil, fmt.Errorf("engine %s takes no parameters", name)
+  }
+
+  switch name {
+  case "synchronous":
+    return SynchronousEngine{}, nil
+  case "in-place":
+    return InPlaceEngine{}, nil
+  case "event-driven":
+    return EventEngine{}, nil
+  }
+  return nil, fmt.Errorf("unknown engine %q, expected %s", name, strings.Join(Engines, ", "))
+}
+
+//EngineName returns the name of an engine, "synchronous" for nil
+func EngineName(e Engine) string {
+  if e == nil {
+    return SynchronousEngine{}.Name()
+  }
+  return e.Name()
+}
+
+func (e SynchronousEngine) Step(r *rand.Rand, n Network, p Pathogen, epoch int) {
+  InfectOnce(r, n, p, epoch)
+}
+
+func (e SynchronousEngine) Name() string {
+  return "synchronous"
+}
+
+func (e InPlaceEngine) Step(r *rand.Rand, n Network, p Pathogen, epoch int) {
+  InfectOnceInPlace(r, n, p, epoch)
+}
+
+func (e InPlaceEngine) Name() string {
+  return "in-place"
+}
@@ -0,0 +1,139 @@
+package epidemic
+
+import (
+  "math/rand"
+  "testing"
+)
+
+//certainPathogen infects every susceptible neighbor of an infectious node, for exactly one timestep, so that the
+//course of an epidemic only depends on the engine and not on the random draws
+func certainPathogen() Pathogen {
+  return NewPathogen("certain", 1000, 0)
+}
+
+//plainNetwork returns a network of pop nodes without contacts in which everyone has the same traits
+func plainNetwork(pop int) Network {
+  n := make(Network, pop)
+  n.InitializeNetwork(rand.New(rand.NewSource(1)))
+  for i := range n {
+    n[i].Susceptibility = 1
+    n[i].Infectiousness = 1
+  }
+  return n
+}
+
+//pathNetwork connects the nodes of a plain network in a line, in the given order of IDs
+func pathNetwork(order []int) Network {
+  n := plainNetwork(len(order))
+  for i := 0; i + 1 < len(order); i++ {
+    n.ConnectUndirected(order[i], order[i + 1])
+  }
+  return n
+}
+
+//infectionEpochs runs an epidemic of the certain pathogen from node zero with engine e and returns the timestep every
+//node was infected in, -1 for never and 0 for node zero
+func infectionEpochs(n Network, zero int, e Engine) []int {
+  r := rand.New(rand.NewSource(1))
+  n[zero].Status = "I"
+  n.SeedInfection(r, 0, certainPathogen())
+  RunEpidemic(r, n, certainPathogen(), e, 0, nil)
+
+  epochs := make([]int, len(n))
+  for i := range n {
+    epochs[i] = -1
+    if n[i].Infections > 0 {
+      epochs[i] = n[i].InfectedAt
+    }
+  }
+  return epochs
+}
+
+//TestSynchronousOrder checks that the synchronous engine spreads the disease one contact further every timestep
+//whatever the order of the IDs, while the in-place engine lets it run along increasing IDs within a timestep, as
+//the original InfectOnce did
+func TestSynchronousOrder(t *testing.T) {
+  up := []int{0, 1, 2, 3, 4, 5}
+  down := []int{5, 4, 3, 2, 1, 0}
+  tests := []struct {
+    name string
+    order []int
+    e Engine
+    //want is the timestep every node along the line is infected in, starting from the patient zero
+    want []int
+  }{
+    {"synchronous, increasing IDs", up, SynchronousEngine{}, []int{0, 1, 2, 3, 4, 5}},
+    {"synchronous, decreasing IDs", down, SynchronousEngine{}, []int{0, 1, 2, 3, 4, 5}},
+    {"in-place, increasing IDs", up, InPlaceEngine{}, []int{0, 1, 1, 1, 1, 1}},
+    {"in-place, decreasing IDs", down, InPlaceEngine{}, []int{0, 1, 2, 3, 4, 5}},
+  }
+
+  for _, test := range tests {
+    epochs := infectionEpochs(pathNetwork(test.order), test.order[0], test.e)
+    for k, id := range test.order {
+      if epochs[id] != test.want[k] {
+        t.Errorf("%s: node %d along the line infected in timestep %d, want %d", test.name, k, epochs[id], test.want[k])
+      }
+    }
+  }
+}
+
+//TestSynchronousPermutation checks that relabelling the nodes of a random network does not change when the
+//synchronous engine infects each of them: the distance from the patient zero, however the IDs are ordered
+func TestSynchronousPermutation(t *testing.T) {
+  const pop = 300
+  r := rand.New(rand.NewSource(3))
+  edges := ErdosRenyiGenerator{3}.edges(r, pop)
+  perm := r.Perm(pop)
+
+  n := plainNetwork(pop)
+  n.connectEdges(edges)
+  permuted := plainNetwork(pop)
+  for k := 0; 2 * k + 1 < len(edges); k++ {
+    permuted.ConnectUndirected(perm[edges[2 * k]], perm[edges[2 * k + 1]])
+  }
+
+  epochs := infectionEpochs(n, 0, SynchronousEngine{})
+  permutedEpochs := infectionEpochs(permuted, perm[0], SynchronousEngine{})
+  reached := 0
+  for i := range n {
+    if epochs[i] != permutedEpochs[perm[i]] {
+      t.Fatalf("node %d infected in timestep %d, but in %d once relabelled %d", i, epochs[i], permutedEpochs[perm[i]],
+        perm[i])
+    } else if epochs[i] > 0 {
+      reached++
+    }
+  }
+  if reached < pop / 2 {
+    t.Errorf("only %d of %d nodes infected, too few to tell", reached, pop)
+  }
+
+  //The in-place engine is faster along increasing IDs, so relabelling changes when the nodes are infected
+  inPlace := infectionEpochs(plainNetworkLike(n), 0, InPlaceEngine{})
+  if equalInts(inPlace, epochs) {
+    t.Errorf("in-place engine infected every node in the same timestep as the synchronous engine")
+  }
+}
+
+//plainNetworkLike returns a fresh plain network with the same contacts as n
+func plainNetworkLike(n Network) Network {
+  fresh := plainNetwork(len(n))
+  for i := range n {
+    for _, neighbor := range n[i].Connections {
+      fresh[i].Connections = append(fresh[i].Connections, fresh[neighbor.ID])
+    }
+  }
+  return fresh
+}
+
+func equalInts(a, b []int) bool {
+  if len(a) != len(b) {
+    return false
+  }
+  for i := range a {
+    if a[i] != b[i] {
+      return false
+    }
+  }
+  return true
+}
@@ -0,0 +1,235 @@
+package epidemic
+
+import (
+  "context"
+  "fmt"
+  "math"
+  "math/rand"
+  "os"
+  "sort"
+)
+
+//ReplicateResult is the outcome of one epidemic in an ensemble. Running the same Scenario with its Seed set to
+//the replicate's seed replays that exact epidemic. Counts maps status descriptions (see ReadStatus) to the
+//number of nodes with that status at the end, and Epochs is the number of timesteps the epidemic lasted.
+//RealizedR0 and Dispersion are the realized R0 and dispersion k of the outbreak, see ReproductionStats,
+//Prediction is what percolation theory expected of the replicate's network and Endemic describes its long run
+//behaviour.
+type ReplicateResult struct {
+  Seed int64
+  Counts map[string]int
+  Epochs int
+  Frailty float64
+  Interference float64
+  RealizedR0 float64
+  Dispersion float64
+  Prediction Prediction
+  Endemic EndemicStats
+}
+
+//Summary holds the usual descriptive statistics of one quantity measured across an ensemble, computed from N
+//values. Lo and Hi are the 2.5th and 97.5th percentiles, so together they give a 95% interval.
+type Summary struct {
+  N int
+  Mean float64
+  Median float64
+  SD float64
+  Lo float64
+  Hi float64
+}
+
+//RunEnsemble runs independent replicates of a scenario, each on a freshly built, vaccinated and seeded network.
+//The seed of every replicate is drawn from a generator seeded with s.Seed, so the whole ensemble can be
+//replayed as well as any single replicate in it. The replicates run in parallel on all the CPUs, see Runner.
+func RunEnsemble(s Scenario, runs int) []ReplicateResult {
+  //The background context is never cancelled, so every replicate finishes
+  results, _ := Runner{}.Ensemble(context.Background(), s, runs)
+  return results
+}
+
+//RunReplicate runs a single epidemic of a scenario from scratch using its own generator seeded with seed.
+func RunReplicate(s Scenario, seed int64) ReplicateResult {
+  r := rand.New(rand.NewSource(seed))
+
+  net := s.BuildNetwork(r)
+  s.Vaccinate(r, net)
+  pred := ScenarioPrediction(s, net)
+  net.SeedInfection(r, s.PatientsZero, s.Pathogen)
+  ts := RunEpidemic(r, net, s.Pathogen, s.Engine, s.MaxEpochs, nil)
+
+  tree := BuildTransmissionTree(net)
+  return ReplicateResult{seed, CountStatuses(net), ts.Duration(), NetworkFrailty(net), NetworkInterference(net), tree.RealizedR0(EarlyGenerations), tree.Dispersion(), pred, NewEndemicStats(ts)}
+}
+
+//meanPrediction averages the percolation predictions of the replicates of an ensemble
+func meanPrediction(results []ReplicateResult) Prediction {
+  var pred Prediction
+  for _, res := range results {
+    pred.Transmissibility += res.Prediction.Transmissibility / float64(len(results))
+    pred.Coverage += res.Prediction.Coverage / float64(len(results))
+    pred.Threshold += res.Prediction.Threshold / float64(len(results))
+    pred.OutbreakSize += res.Prediction.OutbreakSize / float64(len(results))
+    pred.MajorProbability += res.Prediction.MajorProbability / float64(len(results))
+  }
+  pred.Epidemic = pred.Transmissibility > pred.Threshold
+  return pred
+}
+
+//AttackRate returns the fraction of the population that was ever infected in a replicate
+func (res ReplicateResult) AttackRate(pop int) float64 {
+  return float64(res.Counts["ever infected"]) / float64(pop)
+}
+
+//CountMajor returns how many replicates infected at least the fraction major of a population of size pop
+func CountMajor(results []ReplicateResult, pop int, major float64) int {
+  count := 0
+  for _, res := range results {
+    if res.AttackRate(pop) >= major {
+      count++
+    }
+  }
+  return count
+}
+
+//Summarize computes the Summary of a sample. NaN values (for example the frailty of a network with no
+//susceptible nodes left) are left out.
+func Summarize(x []float64) Summary {
+  sorted := make([]float64, 0, len(x))
+  for i := range x {
+    if math.IsNaN(x[i]) == false {
+      sorted = append(sorted, x[i])
+    }
+  }
+  sort.Float64s(sorted)
+
+  var s Summary
+  s.N = len(sorted)
+  if s.N == 0 {
+    nan := math.NaN()
+    return Summary{0, nan, nan, nan, nan, nan}
+  }
+
+  sum := 0.0
+  for i := range sorted {
+    sum += sorted[i]
+  }
+  s.Mean = sum / float64(s.N)
+
+  //Sample standard deviation, which is 0 for a single value
+  sq := 0.0
+  for i := range sorted {
+    sq += (sorted[i] - s.Mean) * (sorted[i] - s.Mean)
+  }
+  if s.N > 1 {
+    s.SD = math.Sqrt(sq / float64(s.N - 1))
+  }
+
+  s.Median = Percentile(sorted, 50)
+  s.Lo = Percentile(sorted, 2.5)
+  s.Hi = Percentile(sorted, 97.5)
+
+  return s
+}
+
+//Percentile returns the q-th percentile (0 <= q <= 100) of an already sorted, non-empty sample, interpolating
+//linearly between the closest ranks.
+func Percentile(sorted []float64, q float64) float64 {
+  pos := q / 100.0 * float64(len(sorted) - 1)
+  below := int(math.Floor(pos))
+  above := int(math.Ceil(pos))
+  frac := pos - float64(below)
+  return sorted[below] * (1 - frac) + sorted[above] * frac
+}
+
+//WriteEnsembleToFile writes the summary statistics of an ensemble to [outName]_ensemble.txt and one line per
+//replicate, including its seed, to [outName]_ensemble.csv. A replicate is a major outbreak if at least the fraction
+//major of the population was infected.
+func WriteEnsembleToFile(results []ReplicateResult, s Scenario, major float64, outName string) error {
+  statuses := []string{"dead", "recovered", "immune", "susceptible", "breakthrough"}
+
+  //Collect every measured quantity across the replicates
+  samples := make(map[string][]float64)
+  for _, res := range results {
+    for _, status := range statuses {
+      samples[status] = append(samples[status], float64(res.Counts[status]))
+    }
+    samples["attack rate"] = append(samples["attack rate"], res.AttackRate(s.Population))
+    samples["duration"] = append(samples["duration"], float64(res.Epochs))
+    samples["frailty"] = append(samples["frailty"], res.Frailty)
+    samples["interference"] = append(samples["interference"], res.Interference)
+    samples["realized R0"] = append(samples["realized R0"], res.RealizedR0)
+    samples["prevalence"] = append(samples["prevalence"], res.Endemic.MeanPrevalence)
+    samples["waves"] = append(samples["waves"], float64(len(res.Endemic.WavePeaks)))
+    //An infinite k (no overdispersion) cannot be averaged, so it is left out like a missing value
+    if math.IsInf(res.Dispersion, 1) {
+      samples["dispersion k"] = append(samples["dispersion k"], math.NaN())
+    } else {
+      samples["dispersion k"] = append(samples["dispersion k"], res.Dispersion)
+    }
+  }
+
+  file, err := os.Create(outName + "_ensemble.txt")
+  if err != nil {
+    return fmt.Errorf("cannot create ensemble statistics file: %w", err)
+  }
+  defer file.Close()
+
+  fmt.Fprintf(file, "%d replicates of %s in a population of %d, %g%% vaccinated (%s), %d patient(s) zero.\n", len(results), s.Pathogen.Name, s.Population, s.VaccineRate, StrategyName(s.Strategy), s.PatientsZero)
+  fmt.Fprintf(file, "Base reproductive ratio %g, mortality rate %g%%. Master random seed: %d\n", s.Pathogen.Ro, s.Pathogen.Lethality * 100, s.Seed)
+  fmt.Fprintf(file, "%s\n", s.Pathogen.Vaccine.Describe())
+  susceptibility, infectiousness := s.Traits()
+  fmt.Fprintf(file, "Susceptibility drawn from %s, infectiousness from %s.\n", susceptibility, infectiousness)
+  fmt.Fprintf(file, "Contact network: %s, stepped with the %s engine\n\n", DescribeGenerator(s.Generator()), EngineName(s.Engine))
+
+  fmt.Fprintf(file, "%-14s %6s %12s %12s %12s %12s %12s\n", "", "n", "mean", "median", "sd", "2.5%", "97.5%")
+  for _, name := range append(statuses, "attack rate", "duration", "frailty", "interference", "realized R0", "dispersion k", "prevalence", "waves") {
+    sum := Summarize(samples[name])
+    fmt.Fprintf(file, "%-14s %6d %12.4f %12.4f %12.4f %12.4f %12.4f\n", name, sum.N, sum.Mean, sum.Median, sum.SD, sum.Lo, sum.Hi)
+  }
+
+  //An outbreak is major if at least the fraction major of the population was infected, otherwise it fizzled out
+  majorCount := CountMajor(results, s.Population, major)
+  majorFrac := float64(majorCount) / float64(len(results))
+  fmt.Fprintf(file, "\nMajor outbreaks (attack rate >= %g): %d of %d (%.4f), fizzled: %d of %d (%.4f)\n", major, majorCount, len(results), majorFrac, len(results) - majorCount, len(results), 1 - majorFrac)
+
+  //Only replicates stopped by s.MaxEpochs can still be circulating
+  if s.MaxEpochs > 0 {
+    persisted := 0
+    for _, res := range results {
+      if res.Endemic.Persisted {
+        persisted++
+      }
+    }
+    fmt.Fprintf(file, "Still circulating after %d timesteps: %d of %d (%.4f)\n", s.MaxEpochs, persisted, len(results), float64(persisted) / float64(len(results)))
+  }
+
+  //Percolation theory, averaged over the networks of the replicates, against the simulated outcome
+  pred := meanPrediction(results)
+  majorAttack := make([]float64, 0)
+  for _, res := range results {
+    if res.AttackRate(s.Population) >= major {
+      majorAttack = append(majorAttack, res.AttackRate(s.Population))
+    }
+  }
+  fmt.Fprintf(file, "\nPercolation theory (mean over the replicates' networks): transmissibility %.4f, epidemic threshold %.4f\n", pred.Transmissibility, pred.Threshold)
+  fmt.Fprintf(file, "%-26s %12s %12s\n", "", "predicted", "simulated")
+  fmt.Fprintf(file, "%-26s %12.4f %12.4f\n", "major outbreak probability", pred.MajorProbability, majorFrac)
+  fmt.Fprintf(file, "%-26s %12.4f %12.4f\n", "major outbreak size", pred.OutbreakSize, Summarize(majorAttack).Mean)
+
+  if err := file.Close(); err != nil {
+    return err
+  }
+
+  csvFile, err := os.Create(outName + "_ensemble.csv")
+  if err != nil {
+    return fmt.Errorf("cannot create ensemble replicates file: %w", err)
+  }
+  defer csvFile.Close()
+
+  fmt.Fprintln(csvFile, "replicate,seed,dead,recovered,immune,susceptible,breakthrough,attack_rate,duration,frailty,interference,realized_r0,dispersion_k,predicted_size,predicted_major,persisted,prevalence,waves")
+  for i, res := range results {
+    fmt.Fprintf(csvFile, "%d,%d,%d,%d,%d,%d,%d,%g,%d,%g,%g,%g,%g,%g,%g,%t,%g,%d\n", i, res.Seed, res.Counts["dead"], res.Counts["recovered"], res.Counts["immune"], res.Counts["susceptible"], res.Counts["breakthrough"], res.AttackRate(s.Population), res.Epochs, res.Frailty, res.Interference, res.RealizedR0, res.Dispersion, res.Prediction.OutbreakSize, res.Prediction.MajorProbability, res.Endemic.Persisted, res.Endemic.MeanPrevalence, len(res.Endemic.WavePeaks))
+  }
+
+  return csvFile.Close()
+}
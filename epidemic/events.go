@@ -0,0 +1,362 @@
+package epidemic
+
+import (
+  "container/heap"
+  "fmt"
+  "io"
+  "math"
+  "math/rand"
+  "os"
+  "strconv"
+)
+
+//The discrete engines give every infectious node one chance per timestep to infect each of its neighbors. The
+//EventEngine instead runs the epidemic in continuous time, as a next-reaction method: every node schedules the
+//time at which it leaves its current status, every infectious node the times of its contacts with each neighbor,
+//and the events are carried out one at a time in order. Timesteps are only used to record the epidemic curve.
+//
+//Contacts between an infectious node i and a neighbor j happen at the rate
+//
+//  -ln(1 - T) / D * infectiousness(i) * susceptibility(j)
+//
+//where T is the transmissibility of the pathogen in the network and D its mean infectious period, so that with
+//the average traits and a fixed infectious period a neighbor is infected with probability T, as in InfectOnce.
+//A contact with a susceptible neighbor infects it, and a contact with a neighbor protected by a leaky vaccine only
+//with the probability the vaccine leaves (see Vaccine.Susceptibility). Waning immunity is lost at the rate
+//-ln(1 - w) for the per-timestep probability w. The latent and infectious periods last Period.Duration.
+//
+//Exponential times between contacts make transmission memoryless. With a general distribution of transmission
+//times instead, an infectious node decides for each neighbor whether it will pass the disease on, with the
+//probability the contacts above would give over its infectious period, and if so when: after a delay drawn from
+//the distribution (see Period.Duration), drawn again until it falls within the infectious period. The neighbor is
+//then infected like after any contact, if it can still be. The distribution is thus cut off at the end of the
+//infectious period, so most of it must fall within the period, see EventEngine.CheckTransmission. A node infectious
+//for too short a time for any delay to fall within it passes nothing on.
+
+//EventEngine steps an epidemic through time with continuous-time events. Transmission is the distribution of the
+//time from the start of infectiousness to a transmission, the zero Period for exponential contacts. If Record is
+//not nil it is called with every event, in order of time.
+type EventEngine struct {
+  Transmission Period
+  Record func(Event)
+}
+
+//Event is something that happened to a node in continuous time: an "infection" (by the node with ID Infector), the
+//node becoming "infectious", its "recovery" or "death", or the "waning" of its immunity. Infector is -1 for all
+//but infections. Epoch is the timestep the event was carried out in, which is not always the one its time rounds
+//up to: events due at the very start of a timestep, such as those of the patients zero at time epoch - 1, belong
+//to it as well (see EventEngine.Step).
+type Event struct {
+  Time float64 `json:"time"`
+  Epoch int `json:"epoch"`
+  Kind string `json:"kind"`
+  Node int `json:"node"`
+  Infector int `json:"infector"`
+}
+
+//schedule holds the upcoming events of a node in the status it was drawn for: at is the time the node leaves that
+//status (+Inf for never) and, for infectious nodes, contacts[k] is the time of its next contact with Connections[k]
+//(+Inf once there are no more before it recovers)
+type schedule struct {
+  status string
+  at float64
+  contacts []float64
+}
+
+//pendingEvent is an event in the queue of one timestep: the node leaving its status, or its contact with
+//Connections[contact]. It is stale, and skipped, once the node no longer has the schedule s or the schedule has
+//moved on.
+type pendingEvent struct {
+  time float64
+  node *Node
+  s *schedule
+  contact int
+}
+
+//eventQueue orders pending events by time, see container/heap
+type eventQueue []pendingEvent
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool { return q[i].time < q[j].time }
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) {
+  *q = append(*q, x.(pendingEvent))
+}
+
+func (q *eventQueue) Pop() interface{} {
+  old := *q
+  e := old[len(old) - 1]
+  *q = old[:len(old) - 1]
+  return e
+}
+
+//eventStep carries out the events of the timestep epoch, those up to time end
+type eventStep struct {
+  r *rand.Rand
+  p Pathogen
+  epoch int
+  end float64
+  //contactRate is the rate of contacts between nodes of average infectiousness and susceptibility
+  contactRate float64
+  //delay is the distribution of transmission times, the zero Period for exponential contacts
+  delay Period
+  queue eventQueue
+  record func(Event)
+}
+
+func (e EventEngine) Name() string {
+  return "event-driven"
+}
+
+//Step carries out every event between the end of the timestep before epoch and the end of epoch, so at times in
+//(epoch - 1, epoch]. Nodes without a schedule for their status, such as the patients zero, are given one starting
+//at epoch - 1.
+func (e EventEngine) Step(r *rand.Rand, n Network, p Pathogen, epoch int) {
+  T := Transmissibility(p.Ro, n)
+  contactRate := math.Inf(1)
+  if T < 1.0 {
+    contactRate = -math.Log(1.0 - T) / math.Max(p.Infectious.Mean, 1.0)
+  }
+
+  step := &eventStep{r, p, epoch, float64(epoch), contactRate, e.Transmission, make(eventQueue, 0), e.Record}
+  for _, node := range n {
+    if node.schedule == nil || node.schedule.status != node.Status {
+      step.plan(node, float64(epoch - 1))
+    } else {
+      step.enqueue(node)
+    }
+  }
+
+  for len(step.queue) > 0 {
+    ev := heap.Pop(&step.queue).(pendingEvent)
+    s := ev.s
+    if ev.node.schedule != s || s.status != ev.node.Status {
+      continue
+    }
+    if ev.contact >= 0 {
+      if s.contacts[ev.contact] == ev.time {
+        step.contact(ev.node, ev.contact, ev.time)
+      }
+    } else if s.at == ev.time {
+      step.transition(ev.node, ev.time)
+    }
+  }
+}
+
+//plan draws the schedule of node for its current status, starting at time t, and queues its events of this
+//timestep. Susceptible and dead nodes have no schedule.
+func (step *eventStep) plan(node *Node, t float64) {
+  if node.Status != "E" && node.Status != "I" && node.Status != "R" && node.Status != "V" {
+    node.schedule = nil
+    return
+  }
+
+  s := &schedule{node.Status, math.Inf(1), nil}
+  switch node.Status {
+  case "E":
+    //With no latent period the node becomes infectious right away, at time t
+    s.at = t + step.p.Latent.Duration(step.r)
+  case "I":
+    //Every infected node is infectious for some time, as with InfectOnce
+    duration := step.p.Infectious.Duration(step.r)
+    if duration <= 0 {
+      duration = 1
+    }
+    s.at = t + duration
+    s.contacts = make([]float64, len(node.Connections))
+    for k := range node.Connections {
+      if step.delay.Dist == "" {
+        s.contacts[k] = step.nextContact(node, k, t, s.at)
+      } else {
+        s.contacts[k] = step.transmissionTime(node, k, t, duration)
+      }
+    }
+  case "R":
+    s.at = t + step.waningTime(step.p.Waning)
+  case "V":
+    s.at = t + step.waningTime(step.p.Vaccine.Waning)
+  }
+  node.schedule = s
+  step.enqueue(node)
+}
+
+//enqueue queues the events of the schedule of node that fall in this timestep
+func (step *eventStep) enqueue(node *Node) {
+  s := node.schedule
+  if s.at <= step.end {
+    heap.Push(&step.queue, pendingEvent{s.at, node, s, -1})
+  }
+  for k, t := range s.contacts {
+    if t <= step.end {
+      heap.Push(&step.queue, pendingEvent{t, node, s, k})
+    }
+  }
+}
+
+//nextContact returns the time of the next contact of the infectious node with Connections[k] after time t, +Inf if
+//it would come after the node stops being infectious at time until
+func (step *eventStep) nextContact(node *Node, k int, t, until float64) float64 {
+  weight := node.Infectiousness * node.Connections[k].Susceptibility
+  if weight <= 0 {
+    return math.Inf(1)
+  }
+  next := t + step.r.ExpFloat64() / (step.contactRate * weight)
+  if next >= until {
+    return math.Inf(1)
+  }
+  return next
+}
+
+//transmissionTime returns the time at which the node, infectious from time t for the given duration, passes the
+//disease on to Connections[k] with a general distribution of transmission times, +Inf if it never does
+func (step *eventStep) transmissionTime(node *Node, k int, t, duration float64) float64 {
+  weight := node.Infectiousness * node.Connections[k].Susceptibility
+  if weight <= 0 || step.r.Float64() >= 1.0 - math.Exp(-step.contactRate * weight * duration) {
+    return math.Inf(1)
+  }
+
+  //An infectious period too short for the distribution to fall within transmits nothing, rather than at some other
+  //time than the distribution gives
+  for tries := 0; tries < transmissionTries; tries++ {
+    delay := step.delay.Duration(step.r)
+    if delay < duration {
+      return t + delay
+    }
+  }
+  return math.Inf(1)
+}
+
+//transmissionTries is the number of transmission times drawn for a contact before giving up, and
+//minTransmissionMass the fraction of transmission times that must fall within the infectious period, see
+//EventEngine.CheckTransmission. With that fraction, all the draws miss once in 170 contacts on average.
+const transmissionTries = 100
+const minTransmissionMass = 0.05
+
+//CheckTransmission returns an error if the distribution of transmission times of the engine falls within the
+//infectious period of pathogen p less than 5% of the time, measured over draws with a fixed seed. Most contacts
+//would then pass nothing on, which makes the epidemic depend on the cut-off rather than on the distribution.
+func (e EventEngine) CheckTransmission(p Pathogen) error {
+  if e.Transmission.Dist == "" {
+    return nil
+  }
+
+  const draws = 10000
+  r := rand.New(rand.NewSource(1))
+  within := 0
+  for i := 0; i < draws; i++ {
+    duration := p.Infectious.Duration(r)
+    if duration <= 0 {
+      duration = 1
+    }
+    if e.Transmission.Duration(r) < duration {
+      within++
+    }
+  }
+
+  if mass := float64(within) / draws; mass < minTransmissionMass {
+    return fmt.Errorf("transmission times %s fall within the infectious period %s %.1f%% of the time, less than the %g%% needed", e.Transmission, p.Infectious, 100 * mass, 100 * minTransmissionMass)
+  }
+  return nil
+}
+
+//waningTime returns how long immunity lost with probability w every timestep lasts, +Inf for lasting immunity
+func (step *eventStep) waningTime(w float64) float64 {
+  if w <= 0 {
+    return math.Inf(1)
+  } else if w >= 1 {
+    return 0
+  }
+  return step.r.ExpFloat64() / -math.Log(1.0 - w)
+}
+
+//contact carries out the contact of the infectious node with Connections[k] at time t and schedules the next one
+func (step *eventStep) contact(node *Node, k int, t float64) {
+  neighbor := node.Connections[k]
+  protection := step.p.Vaccine.Susceptibility(neighbor)
+  if protection > 0 && step.r.Float64() < protection {
+    neighbor.infectedBy(node, step.epoch, t)
+    step.log(t, "infection", neighbor, node)
+    neighbor.Status = "E"
+    step.plan(neighbor, t)
+  }
+
+  s := node.schedule
+  if math.IsInf(step.contactRate, 1) || step.delay.Dist != "" {
+    //A transmissibility of 1 infects every neighbor at the first contact, so there is no need for another, and
+    //general transmission times have a single one
+    s.contacts[k] = math.Inf(1)
+    return
+  }
+  s.contacts[k] = step.nextContact(node, k, t, s.at)
+  if s.contacts[k] <= step.end {
+    heap.Push(&step.queue, pendingEvent{s.contacts[k], node, s, k})
+  }
+}
+
+//transition moves node out of its status at time t: exposed nodes become infectious, infectious nodes die or
+//recover, and recovered or vaccinated nodes lose their immunity
+func (step *eventStep) transition(node *Node, t float64) {
+  switch node.Status {
+  case "E":
+    node.Status = "I"
+    step.log(t, "infectious", node, nil)
+  case "I":
+    if step.r.Float64() <= step.p.LethalityFor(node) {
+      node.Status = "D"
+      step.log(t, "death", node, nil)
+    } else {
+      node.Status = "R"
+      step.log(t, "recovery", node, nil)
+    }
+  case "R", "V":
+    node.Status = "S"
+    step.log(t, "waning", node, nil)
+  }
+  step.plan(node, t)
+}
+
+//log records an event if the engine records them
+func (step *eventStep) log(t float64, kind string, node, infector *Node) {
+  if step.record == nil {
+    return
+  }
+  ev := Event{Time: t, Epoch: step.epoch, Kind: kind, Node: node.ID, Infector: -1}
+  if infector != nil {
+    ev.Infector = infector.ID
+  }
+  step.record(ev)
+}
+
+//WriteEventsCSV writes one line per event: the time, the timestep it was carried out in (see Event), the kind of
+//event and the names of the node and of the infector (empty but for infections), see NodeName
+func WriteEventsCSV(w io.Writer, events []Event, n Network) error {
+  if _, err := fmt.Fprintln(w, "time,epoch,event,node,infector"); err != nil {
+    return err
+  }
+  for _, e := range events {
+    infector := ""
+    if e.Infector >= 0 {
+      infector = NodeName(n[e.Infector])
+    }
+    _, err := fmt.Fprintf(w, "%s,%d,%s,%s,%s\n", strconv.FormatFloat(e.Time, 'g', -1, 64), e.Epoch, e.Kind, NodeName(n[e.Node]), infector)
+    if err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+//WriteEventsToFile writes the events of an epidemic in network n to [outName]_events.csv
+func WriteEventsToFile(events []Event, n Network, outName string) error {
+  file, err := os.Create(outName + "_events.csv")
+  if err != nil {
+    return fmt.Errorf("cannot create events file: %w", err)
+  }
+  defer file.Close()
+
+  if err := WriteEventsCSV(file, events, n); err != nil {
+    return fmt.Errorf("writing %s_events.csv: %w", outName, err)
+  }
+  return file.Close()
+}
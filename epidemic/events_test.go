@@ -0,0 +1,119 @@
+package epidemic
+
+import (
+  "bytes"
+  "math"
+  "math/rand"
+  "strconv"
+  "strings"
+  "testing"
+)
+
+//eventTestScenario is an epidemic on a small Erdős–Rényi network in which everyone has the same traits, so that
+//every contact transmits with the same probability in every engine
+func eventTestScenario(t *testing.T) Scenario {
+  g, err := ParseGenerator("er:k=6", 400)
+  if err != nil {
+    t.Fatal(err)
+  }
+  constant := Trait{Dist: "constant", Mean: 1}
+  return Scenario{Pathogen: NewPathogen("test", 1.6, 0), Population: 400, PatientsZero: 5, Network: g,
+    Susceptibility: constant, Infectiousness: constant}
+}
+
+//meanFinalSize runs epidemics with engine e on the network the seed 1 builds for scenario s, each with its own seed,
+//and returns the mean fraction of the population ever infected
+func meanFinalSize(s Scenario, e Engine, runs int) float64 {
+  total := 0.0
+  for i := 0; i < runs; i++ {
+    net := s.BuildNetwork(rand.New(rand.NewSource(1)))
+    r := rand.New(rand.NewSource(int64(1000 + i)))
+    net.SeedInfection(r, s.PatientsZero, s.Pathogen)
+    RunEpidemic(r, net, s.Pathogen, e, 0, nil)
+    total += float64(CountStatuses(net)["ever infected"]) / float64(len(net))
+  }
+  return total / float64(runs)
+}
+
+//TestEventEngineFinalSize checks that the event-driven engine infects as many people on average as the synchronous
+//one. With a fixed infectious period every contact transmits with the same probability T in both, so the final
+//sizes follow the same distribution even though the epidemics unfold differently in time.
+func TestEventEngineFinalSize(t *testing.T) {
+  s := eventTestScenario(t)
+  const runs = 300
+  synchronous := meanFinalSize(s, SynchronousEngine{}, runs)
+  events := meanFinalSize(s, EventEngine{}, runs)
+  if synchronous < 0.2 {
+    t.Fatalf("synchronous mean final size %g is too small to compare", synchronous)
+  }
+  if math.Abs(events - synchronous) > 0.05 {
+    t.Errorf("event-driven mean final size %g, synchronous %g", events, synchronous)
+  }
+
+  //Transmission times within the infectious period keep the probability of transmission, and so the final size
+  s.Pathogen.Infectious = Period{"fixed", 4}
+  synchronous = meanFinalSize(s, SynchronousEngine{}, runs)
+  events = meanFinalSize(s, EventEngine{Transmission: Period{"fixed", 1}}, runs)
+  if math.Abs(events - synchronous) > 0.05 {
+    t.Errorf("event-driven mean final size %g with fixed transmission times, synchronous %g", events, synchronous)
+  }
+}
+
+//TestEventEpochs checks that every event is recorded and written with the timestep it was carried out in, including
+//those at the very start of a timestep
+func TestEventEpochs(t *testing.T) {
+  s := eventTestScenario(t)
+  //Vaccines that wear off right away wane at time 0, at the very start of the first timestep
+  s.Pathogen.Vaccine.Waning = 1
+  net := s.BuildNetwork(rand.New(rand.NewSource(1)))
+  r := rand.New(rand.NewSource(2))
+  net.Vaccinate(r, 0.3)
+  net.SeedInfection(r, s.PatientsZero, s.Pathogen)
+
+  events := make([]Event, 0)
+  e := EventEngine{Record: func(ev Event) {
+    events = append(events, ev)
+  }}
+  RunEpidemic(r, net, s.Pathogen, e, 20, nil)
+
+  starts := 0
+  for _, ev := range events {
+    if ev.Time < float64(ev.Epoch - 1) || ev.Time > float64(ev.Epoch) {
+      t.Fatalf("%s of node %d at time %g recorded in timestep %d", ev.Kind, ev.Node, ev.Time, ev.Epoch)
+    } else if ev.Time == float64(ev.Epoch - 1) {
+      starts++
+    }
+  }
+  if starts == 0 {
+    t.Errorf("no events at the start of a timestep among %d", len(events))
+  }
+
+  var buf bytes.Buffer
+  if err := WriteEventsCSV(&buf, events, net); err != nil {
+    t.Fatal(err)
+  }
+  lines := strings.Split(strings.TrimSpace(buf.String()), "\n")[1:]
+  for i, line := range lines {
+    if epoch := strings.Split(line, ",")[1]; epoch != strconv.Itoa(events[i].Epoch) {
+      t.Fatalf("line %q has timestep %s, want %d", line, epoch, events[i].Epoch)
+    }
+  }
+}
+
+//TestCheckTransmission checks that transmission times that (nearly) never fall within the infectious period are
+//rejected
+func TestCheckTransmission(t *testing.T) {
+  p := NewPathogen("test", 2, 0)
+  if err := (EventEngine{}).CheckTransmission(p); err != nil {
+    t.Errorf("exponential contacts: %v", err)
+  }
+  if err := (EventEngine{Transmission: Period{"geometric", 1}}).CheckTransmission(p); err != nil {
+    t.Errorf("geometric:1 within fixed:1: %v", err)
+  }
+  if err := (EventEngine{Transmission: Period{"fixed", 5}}).CheckTransmission(p); err == nil {
+    t.Errorf("fixed:5 within fixed:1: got no error")
+  }
+  if err := (EventEngine{Transmission: Period{"geometric", 100}}).CheckTransmission(p); err == nil {
+    t.Errorf("geometric:100 within fixed:1: got no error")
+  }
+}
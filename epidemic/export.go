@@ -0,0 +1,216 @@
+package epidemic
+
+import (
+  "bufio"
+  "encoding/xml"
+  "fmt"
+  "io"
+  "os"
+  "strconv"
+  "strings"
+)
+
+//NetworkFormats lists the formats a network can be exported to, see WriteNetwork
+var NetworkFormats = []string{"edgelist", "graphml", "gexf"}
+
+//networkExtensions maps every export format to the extension of its file
+var networkExtensions = map[string]string{"edgelist": "edges", "graphml": "graphml", "gexf": "gexf"}
+
+//NodeName returns the name of a node in exported files: its label if it was read from a file, its ID otherwise
+func NodeName(node *Node) string {
+  if node.Label != "" {
+    return node.Label
+  }
+  return strconv.Itoa(node.ID)
+}
+
+//IsDirected returns true if some connection of the network is not reciprocated, as in the legacy directed Meyers
+//network (see ConnectNetworkDirected)
+func (n Network) IsDirected() bool {
+  edges := make(map[[2]int]bool)
+  for _, node := range n {
+    for _, c := range node.Connections {
+      edges[[2]int{node.ID, c.ID}] = true
+    }
+  }
+  for edge := range edges {
+    if edges[[2]int{edge[1], edge[0]}] == false {
+      return true
+    }
+  }
+  return false
+}
+
+//Edges returns every edge of the network once, in order of the nodes' IDs. Undirected edges are listed from the
+//node with the lower ID.
+func (n Network) Edges() [][2]*Node {
+  directed := n.IsDirected()
+  edges := make([][2]*Node, 0)
+  for _, node := range n {
+    for _, c := range node.Connections {
+      if directed || node.ID < c.ID {
+        edges = append(edges, [2]*Node{node, c})
+      }
+    }
+  }
+  return edges
+}
+
+//exportAttributes returns the node attributes written to GraphML and GEXF, in order: the name of the attribute,
+//its type and the value for a node. Nodes that were never infected have no infected_at and infected_by.
+func exportAttributes(node *Node) [][3]string {
+  infectedAt, infectedBy := "", ""
+  if node.Infections > 0 {
+    infectedAt = strconv.Itoa(node.InfectedAt)
+    if node.InfectedBy != nil {
+      infectedBy = NodeName(node.InfectedBy)
+    }
+  }
+  return [][3]string{
+    {"vulnerability", "double", strconv.FormatFloat(node.Vulnerability, 'g', -1, 64)},
+    {"susceptibility", "double", strconv.FormatFloat(node.Susceptibility, 'g', -1, 64)},
+    {"infectiousness", "double", strconv.FormatFloat(node.Infectiousness, 'g', -1, 64)},
+    {"age", "string", node.Age},
+    {"final_status", "string", node.Status},
+    {"infections", "int", strconv.Itoa(node.Infections)},
+    {"infected_at", "int", infectedAt},
+    {"infected_by", "string", infectedBy},
+  }
+}
+
+//escape makes s safe to use in XML text and attribute values
+func escape(s string) string {
+  var b strings.Builder
+  xml.EscapeText(&b, []byte(s))
+  return b.String()
+}
+
+//WriteNetwork writes the network in one of NetworkFormats:
+//
+//  edgelist  one edge per line, the names of the two nodes separated by a space (see NodeName)
+//  graphml   GraphML, with the attributes of every node
+//  gexf      GEXF 1.2, for Gephi, with the attributes of every node
+//
+//The node attributes are vulnerability, susceptibility, infectiousness, age, final_status (the status letter at the
+//time of writing), infections, and the time and infector of the latest infection as infected_at and infected_by.
+//final_status is named so that the exported file can be read back with ReadContactNetwork, which only accepts
+//initial statuses.
+func WriteNetwork(w io.Writer, n Network, format string) error {
+  buf := bufio.NewWriter(w)
+  switch format {
+  case "edgelist":
+    writeEdgeList(buf, n)
+  case "graphml":
+    writeGraphML(buf, n)
+  case "gexf":
+    writeGEXF(buf, n)
+  default:
+    return fmt.Errorf("unknown network format %q, expected edgelist, graphml or gexf", format)
+  }
+  return buf.Flush()
+}
+
+func writeEdgeList(w io.Writer, n Network) {
+  //Nodes without any edges are listed on their own so that they are not lost
+  for _, node := range n {
+    if len(node.Connections) == 0 {
+      fmt.Fprintln(w, NodeName(node))
+    }
+  }
+  for _, e := range n.Edges() {
+    fmt.Fprintln(w, NodeName(e[0]), NodeName(e[1]))
+  }
+}
+
+func writeGraphML(w io.Writer, n Network) {
+  edgeDefault := "undirected"
+  if n.IsDirected() {
+    edgeDefault = "directed"
+  }
+
+  fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+  fmt.Fprintln(w, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+  if len(n) > 0 {
+    for i, attr := range exportAttributes(n[0]) {
+      fmt.Fprintf(w, "  <key id=\"d%d\" for=\"node\" attr.name=\"%s\" attr.type=\"%s\"/>\n", i, attr[0], attr[1])
+    }
+  }
+  fmt.Fprintf(w, "  <graph id=\"G\" edgedefault=\"%s\">\n", edgeDefault)
+  for _, node := range n {
+    fmt.Fprintf(w, "    <node id=\"%s\">", escape(NodeName(node)))
+    for i, attr := range exportAttributes(node) {
+      if attr[2] != "" {
+        fmt.Fprintf(w, "<data key=\"d%d\">%s</data>", i, escape(attr[2]))
+      }
+    }
+    fmt.Fprintln(w, "</node>")
+  }
+  for _, e := range n.Edges() {
+    fmt.Fprintf(w, "    <edge source=\"%s\" target=\"%s\"/>\n", escape(NodeName(e[0])), escape(NodeName(e[1])))
+  }
+  fmt.Fprintln(w, "  </graph>")
+  fmt.Fprintln(w, "</graphml>")
+}
+
+func writeGEXF(w io.Writer, n Network) {
+  edgeType := "undirected"
+  if n.IsDirected() {
+    edgeType = "directed"
+  }
+
+  fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+  fmt.Fprintln(w, `<gexf xmlns="http://www.gexf.net/1.2draft" version="1.2">`)
+  fmt.Fprintf(w, "  <graph mode=\"static\" defaultedgetype=\"%s\">\n", edgeType)
+  fmt.Fprintln(w, `    <attributes class="node">`)
+  if len(n) > 0 {
+    for i, attr := range exportAttributes(n[0]) {
+      gexfType := attr[1]
+      if gexfType == "int" {
+        gexfType = "integer"
+      }
+      fmt.Fprintf(w, "      <attribute id=\"%d\" title=\"%s\" type=\"%s\"/>\n", i, attr[0], gexfType)
+    }
+  }
+  fmt.Fprintln(w, `    </attributes>`)
+
+  fmt.Fprintln(w, `    <nodes>`)
+  for _, node := range n {
+    fmt.Fprintf(w, "      <node id=\"%s\" label=\"%s\"><attvalues>", escape(NodeName(node)), escape(NodeName(node)))
+    for i, attr := range exportAttributes(node) {
+      if attr[2] != "" {
+        fmt.Fprintf(w, "<attvalue for=\"%d\" value=\"%s\"/>", i, escape(attr[2]))
+      }
+    }
+    fmt.Fprintln(w, "</attvalues></node>")
+  }
+  fmt.Fprintln(w, `    </nodes>`)
+
+  fmt.Fprintln(w, `    <edges>`)
+  for i, e := range n.Edges() {
+    fmt.Fprintf(w, "      <edge id=\"%d\" source=\"%s\" target=\"%s\"/>\n", i, escape(NodeName(e[0])), escape(NodeName(e[1])))
+  }
+  fmt.Fprintln(w, `    </edges>`)
+  fmt.Fprintln(w, `  </graph>`)
+  fmt.Fprintln(w, `</gexf>`)
+}
+
+//WriteNetworkToFile writes the network in the given format (see WriteNetwork) to [outName]_network.edges,
+//[outName]_network.graphml or [outName]_network.gexf, returning the name of the file
+func WriteNetworkToFile(n Network, format, outName string) (string, error) {
+  ext, ok := networkExtensions[format]
+  if ok == false {
+    return "", fmt.Errorf("unknown network format %q, expected edgelist, graphml or gexf", format)
+  }
+  fileName := outName + "_network." + ext
+
+  file, err := os.Create(fileName)
+  if err != nil {
+    return "", fmt.Errorf("cannot create network file: %w", err)
+  }
+  defer file.Close()
+
+  if err := WriteNetwork(file, n, format); err != nil {
+    return "", fmt.Errorf("writing %s: %w", fileName, err)
+  }
+  return fileName, file.Close()
+}
@@ -0,0 +1,555 @@
+package epidemic
+
+import (
+  "bufio"
+  "fmt"
+  "math"
+  "math/rand"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+//Generator connects the nodes of an initialized network (see InitializeNetwork) into a contact network. Name and
+//Parameters describe the generator in the statistics output.
+type Generator interface {
+  Connect(r *rand.Rand, n Network)
+  Name() string
+  Parameters() map[string]float64
+}
+
+//MeyersGenerator draws the degree of every node from the power-law distribution of Meyers et al. and connects the
+//nodes with undirected edges, see ConnectNetwork. Directed keeps the original one-way connections of
+//ConnectNetworkDirected instead.
+type MeyersGenerator struct {
+  Alpha float64
+  Kappa float64
+  C float64
+  Directed bool
+}
+
+//ErdosRenyiGenerator connects every pair of nodes independently with the probability that gives MeanDegree
+//neighbors on average.
+type ErdosRenyiGenerator struct {
+  MeanDegree float64
+}
+
+//WattsStrogatzGenerator builds a small world: a ring where every node is connected to its K nearest neighbors,
+//whose edges are then rewired to a random node with probability Beta.
+type WattsStrogatzGenerator struct {
+  K int
+  Beta float64
+}
+
+//BarabasiAlbertGenerator grows a scale-free network by preferential attachment. Every node joins with M edges
+//to existing nodes, chosen with probability proportional to their degree.
+type BarabasiAlbertGenerator struct {
+  M int
+}
+
+//ConfigurationGenerator connects nodes at random so that node i has degree Degrees[i], without self loops or
+//repeated edges (see connectStubs).
+type ConfigurationGenerator struct {
+  Degrees []int
+}
+
+//LatticeGenerator lays the nodes out on a grid Width nodes wide, row by row, and connects each node to the
+//nodes above, below, left and right of it. A Width of 0 makes the grid as square as possible, matching the
+//way DrawNetwork lays out nodes.
+type LatticeGenerator struct {
+  Width int
+}
+
+//NewMeyersGenerator returns the generator BuildNetwork has always used: the parameters of Meyers et al. with a
+//constant C of a tenth of the population.
+func NewMeyersGenerator(pop int) MeyersGenerator {
+  return MeyersGenerator{MeyersAlpha, MeyersKappa, float64(pop) / 10.0, false}
+}
+
+//ParseGenerator reads a generator written as "name" or "name:key=value,key=value", for example "ws:k=10,beta=0.1".
+//pop is the size of the population, which the default Meyers constant C depends on. The names and their keys are
+//
+//  meyers           alpha, kappa, C (defaults 2, 94.2 and pop/10), and directed (default false)
+//  erdos-renyi, er  k, the mean degree (default 10)
+//  watts-strogatz, ws  k, the even number of ring neighbors (default 10), and beta, the rewiring probability
+//                   (default 0.1)
+//  barabasi-albert, ba m, the edges of every new node (default 5)
+//  configuration, config  file, a file with the degree of every node, one per line
+//  lattice          width (default 0, as square as possible)
+//  file             path, a contact network file read with ReadContactNetwork, its format (default from the
+//                   extension) and attrs, a CSV file of node attributes (see ReadNodeAttributes). pop is ignored.
+//
+//Every value must be a finite number, and the k of watts-strogatz, m and width whole numbers. The k of erdos-renyi
+//and watts-strogatz and m cannot be more than pop - 1, as nobody has more contacts than there are other people, and
+//width cannot be more than pop. Meyers alpha must not be negative.
+func ParseGenerator(spec string, pop int) (Generator, error) {
+  name := spec
+  params := make(map[string]string)
+  if strings.Contains(spec, ":") {
+    parts := strings.SplitN(spec, ":", 2)
+    name = parts[0]
+    for _, kv := range strings.Split(parts[1], ",") {
+      pair := strings.SplitN(kv, "=", 2)
+      if len(pair) != 2 {
+        return nil, fmt.Errorf("network parameter %q should be key=value", kv)
+      }
+      params[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+    }
+  }
+
+  //float reads the parameter key, removing it from params so that unknown keys can be reported at the end. A value
+  //that is given must be a finite number no more than max, which keeps the network within the population.
+  var err error
+  float := func(key string, def, max float64) float64 {
+    s, ok := params[key]
+    if ok == false || err != nil {
+      return def
+    }
+    delete(params, key)
+    v, errP := strconv.ParseFloat(s, 64)
+    if errP != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+      err = fmt.Errorf("unable to parse network parameter %s=%q as a finite number", key, s)
+      return def
+    } else if v > max {
+      err = fmt.Errorf("network parameter %s=%s must not be more than %g for a population of %d", key, s, max, pop)
+      return def
+    }
+    return v
+  }
+  //whole reads the parameter key like float, as a whole number
+  whole := func(key string, def, max int) int {
+    v := float(key, float64(def), float64(max))
+    if err == nil && v != math.Trunc(v) {
+      err = fmt.Errorf("network parameter %s=%g must be a whole number", key, v)
+    }
+    if err != nil {
+      return def
+    }
+    return int(math.Max(v, math.MinInt32))
+  }
+  unbounded := math.Inf(1)
+
+  var g Generator
+  switch name {
+  case "meyers":
+    m := NewMeyersGenerator(pop)
+    m = MeyersGenerator{float("alpha", m.Alpha, unbounded), float("kappa", m.Kappa, unbounded), float("C", m.C, unbounded),
+      false}
+    if d, ok := params["directed"]; ok {
+      delete(params, "directed")
+      dir, errB := strconv.ParseBool(d)
+      if errB != nil && err == nil {
+        err = fmt.Errorf("unable to parse network parameter directed=%q", d)
+      }
+      m.Directed = dir
+    }
+    if err == nil && (m.Kappa <= 0 || m.C <= 0) {
+      err = fmt.Errorf("meyers kappa and C must be greater than 0")
+    } else if err == nil && m.Alpha < 0 {
+      err = fmt.Errorf("meyers alpha must not be negative")
+    }
+    g = m
+  case "erdos-renyi", "er":
+    er := ErdosRenyiGenerator{float("k", 10, float64(pop - 1))}
+    if err == nil && er.MeanDegree < 0 {
+      err = fmt.Errorf("erdos-renyi k must not be negative")
+    }
+    g = er
+  case "watts-strogatz", "ws":
+    ws := WattsStrogatzGenerator{whole("k", 10, pop - 1), float("beta", 0.1, unbounded)}
+    if err == nil && (ws.K < 2 || ws.K % 2 != 0) {
+      err = fmt.Errorf("watts-strogatz k must be an even number of at least 2")
+    } else if err == nil && (ws.Beta < 0 || ws.Beta > 1) {
+      err = fmt.Errorf("watts-strogatz beta must be between 0 and 1")
+    }
+    g = ws
+  case "barabasi-albert", "ba":
+    ba := BarabasiAlbertGenerator{whole("m", 5, pop - 1)}
+    if err == nil && ba.M < 1 {
+      err = fmt.Errorf("barabasi-albert m must be at least 1")
+    }
+    g = ba
+  case "configuration", "config":
+    file, ok := params["file"]
+    delete(params, "file")
+    if ok == false {
+      return nil, fmt.Errorf("configuration needs a degree sequence, as configuration:file=degrees.txt")
+    }
+    degrees, errD := ReadDegreeSequence(file)
+    if errD != nil {
+      return nil, errD
+    } else if len(degrees) != pop {
+      return nil, fmt.Errorf("%s has %d degrees but the population is %d", file, len(degrees), pop)
+    }
+    g = ConfigurationGenerator{degrees}
+  case "file":
+    path, ok := params["path"]
+    if ok == false {
+      return nil, fmt.Errorf("file needs a network file, as file:path=contacts.graphml")
+    }
+    c, errR := ReadContactNetwork(path, params["format"])
+    if errR != nil {
+      return nil, errR
+    }
+    if attrs, ok := params["attrs"]; ok {
+      if errR = ReadNodeAttributes(c, attrs); errR != nil {
+        return nil, errR
+      }
+    }
+    delete(params, "path")
+    delete(params, "format")
+    delete(params, "attrs")
+    g = c
+  case "lattice":
+    lat := LatticeGenerator{whole("width", 0, pop)}
+    if err == nil && lat.Width < 0 {
+      err = fmt.Errorf("lattice width must not be negative")
+    }
+    g = lat
+  default:
+    return nil, fmt.Errorf("unknown network %q, expected meyers, erdos-renyi, watts-strogatz, barabasi-albert, configuration, lattice or file", name)
+  }
+
+  if err != nil {
+    return nil, err
+  }
+  for key := range params {
+    return nil, fmt.Errorf("unknown parameter %q for network %s", key, name)
+  }
+  return g, nil
+}
+
+//ReadDegreeSequence reads a degree sequence from a file, one non-negative integer per line. Blank lines and lines
+//starting with '#' are skipped.
+func ReadDegreeSequence(filePath string) ([]int, error) {
+  file, err := os.Open(filePath)
+  if err != nil {
+    return nil, fmt.Errorf("reading degree sequence: %w", err)
+  }
+  defer file.Close()
+
+  degrees := make([]int, 0)
+  scanner := bufio.NewScanner(file)
+  lineNum := 0
+  for scanner.Scan() {
+    lineNum++
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" || strings.HasPrefix(line, "#") {
+      continue
+    }
+    d, errA := strconv.Atoi(line)
+    if errA != nil || d < 0 {
+      return nil, fmt.Errorf("%s line %d: degree %q must be a non-negative integer", filePath, lineNum, line)
+    }
+    degrees = append(degrees, d)
+  }
+  return degrees, scanner.Err()
+}
+
+//edgeSet remembers which undirected edges have been added, so repeats can be rejected in constant time
+type edgeSet map[[2]int]bool
+
+func (e edgeSet) has(a, b int) bool {
+  if a > b {
+    a, b = b, a
+  }
+  return e[[2]int{a, b}]
+}
+
+//add records the edge between a and b, returning false if it was already there or is a self loop
+func (e edgeSet) add(a, b int) bool {
+  if a == b || e.has(a, b) {
+    return false
+  }
+  if a > b {
+    a, b = b, a
+  }
+  e[[2]int{a, b}] = true
+  return true
+}
+
+//ConnectUndirected adds a reciprocal connection between nodes a and b of the network
+func (n Network) ConnectUndirected(a, b int) {
+  n[a].Connections = append(n[a].Connections, n[b])
+  n[b].Connections = append(n[b].Connections, n[a])
+}
+
+//connectEdges connects the network with the undirected edges listed in pairs, edge k joining nodes edges[2k] and
+//edges[2k + 1], in order. The generators that can list their edges up front do so, so that a CompactNetwork can
+//be connected with the same edges (see CompactNetwork.setEdges).
+func (n Network) connectEdges(edges []int32) {
+  for k := 0; 2 * k + 1 < len(edges); k++ {
+    n.ConnectUndirected(int(edges[2 * k]), int(edges[2 * k + 1]))
+  }
+}
+
+func (g MeyersGenerator) Connect(r *rand.Rand, n Network) {
+  if g.Directed {
+    n.ConnectNetworkDirected(r, g.Alpha, g.Kappa, g.C)
+  } else {
+    n.ConnectNetwork(r, g.Alpha, g.Kappa, g.C)
+  }
+}
+
+func (g MeyersGenerator) Name() string {
+  return "meyers"
+}
+
+func (g MeyersGenerator) Parameters() map[string]float64 {
+  params := map[string]float64{"alpha": g.Alpha, "kappa": g.Kappa, "C": g.C}
+  if g.Directed {
+    params["directed"] = 1
+  }
+  return params
+}
+
+func (g ErdosRenyiGenerator) Connect(r *rand.Rand, n Network) {
+  n.connectEdges(g.edges(r, len(n)))
+}
+
+//edges lists the edges of an Erdős–Rényi network of pop nodes in pairs, see Network.connectEdges
+func (g ErdosRenyiGenerator) edges(r *rand.Rand, pop int) []int32 {
+  edges := make([]int32, 0)
+  if pop < 2 {
+    return edges
+  }
+  p := g.MeanDegree / float64(pop - 1)
+  if p <= 0 {
+    return edges
+  }
+
+  //Rather than flipping a coin for each of the n^2/2 pairs, skip ahead a geometrically distributed number of
+  //pairs to the next edge (Batagelj and Brandes). Pairs (v, w) with w < v are visited in order.
+  logq := math.Log(1.0 - p)
+  v := 1
+  w := -1
+  for v < pop {
+    if p >= 1.0 {
+      w++
+    } else {
+      w += 1 + int(math.Log(1.0 - r.Float64()) / logq)
+    }
+    for w >= v && v < pop {
+      w -= v
+      v++
+    }
+    if v < pop {
+      edges = append(edges, int32(v), int32(w))
+    }
+  }
+  return edges
+}
+
+func (g ErdosRenyiGenerator) Name() string {
+  return "erdos-renyi"
+}
+
+func (g ErdosRenyiGenerator) Parameters() map[string]float64 {
+  return map[string]float64{"k": g.MeanDegree}
+}
+
+func (g WattsStrogatzGenerator) Connect(r *rand.Rand, n Network) {
+  n.connectEdges(g.edges(r, len(n)))
+}
+
+//edges lists the edges of a small world of pop nodes in pairs, see Network.connectEdges
+func (g WattsStrogatzGenerator) edges(r *rand.Rand, pop int) []int32 {
+  //A ring needs more nodes than neighbors, otherwise just connect everyone
+  half := g.K / 2
+  if pop <= g.K {
+    edges := make([]int32, 0)
+    for a := 0; a < pop; a++ {
+      for b := a + 1; b < pop; b++ {
+        edges = append(edges, int32(a), int32(b))
+      }
+    }
+    return edges
+  }
+
+  //Build the ring first: edge a * half + j - 1 goes from node a to the node j places further on
+  edges := make([]int32, 0, 2 * pop * half)
+  for a := 0; a < pop; a++ {
+    for j := 1; j <= half; j++ {
+      edges = append(edges, int32(a), int32((a + j) % pop))
+    }
+  }
+
+  //Rewiring only moves the far end of an edge, so nodes a and b are connected if one of the half edges from
+  //either of them leads to the other
+  connected := func(a, b int32) bool {
+    for j := 0; j < half; j++ {
+      if edges[2 * (int(a) * half + j) + 1] == b || edges[2 * (int(b) * half + j) + 1] == a {
+        return true
+      }
+    }
+    return false
+  }
+
+  //Then rewire the far end of each edge with probability beta to a node not yet connected
+  for i := 0; i < pop * half; i++ {
+    if r.Float64() >= g.Beta {
+      continue
+    }
+    a := edges[2 * i]
+    //Give up on nodes that are already connected to (nearly) everyone
+    for tries := 0; tries < pop; tries++ {
+      target := int32(r.Intn(pop))
+      if target != a && connected(a, target) == false {
+        edges[2 * i + 1] = target
+        break
+      }
+    }
+  }
+  return edges
+}
+
+func (g WattsStrogatzGenerator) Name() string {
+  return "watts-strogatz"
+}
+
+func (g WattsStrogatzGenerator) Parameters() map[string]float64 {
+  return map[string]float64{"k": float64(g.K), "beta": g.Beta}
+}
+
+func (g BarabasiAlbertGenerator) Connect(r *rand.Rand, n Network) {
+  n.connectEdges(g.edges(r, len(n)))
+}
+
+//edges lists the edges of a scale-free network of pop nodes in pairs, see Network.connectEdges
+func (g BarabasiAlbertGenerator) edges(r *rand.Rand, pop int) []int32 {
+  //Start from a fully connected core of M + 1 nodes
+  core := g.M + 1
+  if core > pop {
+    core = pop
+  }
+
+  //Every node appears in ends once per edge, so a uniform draw from ends picks nodes proportionally to degree.
+  //The edges are listed in the order they are added, so ends is the list of edges as well.
+  ends := make([]int32, 0, 2 * g.M * pop)
+  for a := 0; a < core; a++ {
+    for b := a + 1; b < core; b++ {
+      ends = append(ends, int32(a), int32(b))
+    }
+  }
+
+  for v := core; v < pop; v++ {
+    targets := make([]int, 0, g.M)
+    for len(targets) < g.M {
+      target := int(ends[r.Intn(len(ends))])
+      if IsIn(targets, target) == false {
+        targets = append(targets, target)
+      }
+    }
+    for _, target := range targets {
+      ends = append(ends, int32(v), int32(target))
+    }
+  }
+  return ends
+}
+
+func (g BarabasiAlbertGenerator) Name() string {
+  return "barabasi-albert"
+}
+
+func (g BarabasiAlbertGenerator) Parameters() map[string]float64 {
+  return map[string]float64{"m": float64(g.M)}
+}
+
+func (g ConfigurationGenerator) Connect(r *rand.Rand, n Network) {
+  connectStubs(r, n, g.Degrees)
+}
+
+//connectStubs gives node i degrees[i] stubs (half edges), shuffles them and joins them in pairs. A pair that would
+//make a self loop or repeat an edge is put back and the leftover stubs are shuffled and paired again, until they
+//are all used or a round makes no progress. Only then are the last stubs dropped, as is one stub if the degrees
+//add up to an odd number, so every node ends up with its degree unless that is impossible.
+func connectStubs(r *rand.Rand, n Network, degrees []int) {
+  stubs := make([]int, 0)
+  for i := range n {
+    for d := 0; d < degrees[i]; d++ {
+      stubs = append(stubs, i)
+    }
+  }
+
+  seen := make(edgeSet)
+  for len(stubs) > 1 {
+    r.Shuffle(len(stubs), func(i, j int) {
+      stubs[i], stubs[j] = stubs[j], stubs[i]
+    })
+
+    leftover := make([]int, 0)
+    for i := 0; i + 1 < len(stubs); i += 2 {
+      if seen.add(stubs[i], stubs[i + 1]) {
+        n.ConnectUndirected(stubs[i], stubs[i + 1])
+      } else {
+        leftover = append(leftover, stubs[i], stubs[i + 1])
+      }
+    }
+
+    if len(leftover) == len(stubs) - len(stubs) % 2 {
+      break
+    }
+    stubs = leftover
+  }
+}
+
+func (g ConfigurationGenerator) Name() string {
+  return "configuration"
+}
+
+func (g ConfigurationGenerator) Parameters() map[string]float64 {
+  total := 0
+  for _, d := range g.Degrees {
+    total += d
+  }
+  return map[string]float64{"nodes": float64(len(g.Degrees)), "k": float64(total) / float64(len(g.Degrees))}
+}
+
+func (g LatticeGenerator) Connect(r *rand.Rand, n Network) {
+  n.connectEdges(g.edges(len(n)))
+}
+
+//edges lists the edges of a lattice of pop nodes in pairs, see Network.connectEdges
+func (g LatticeGenerator) edges(pop int) []int32 {
+  width := g.Width
+  if width == 0 {
+    width = int(math.Ceil(math.Sqrt(float64(pop))))
+  }
+
+  edges := make([]int32, 0, 4 * pop)
+  for i := 0; i < pop; i++ {
+    //Right neighbor on the same row, and the neighbor on the next row
+    if (i + 1) % width != 0 && i + 1 < pop {
+      edges = append(edges, int32(i), int32(i + 1))
+    }
+    if i + width < pop {
+      edges = append(edges, int32(i), int32(i + width))
+    }
+  }
+  return edges
+}
+
+func (g LatticeGenerator) Name() string {
+  return "lattice"
+}
+
+func (g LatticeGenerator) Parameters() map[string]float64 {
+  return map[string]float64{"width": float64(g.Width)}
+}
+
+//DescribeGenerator writes a generator as "name (key=value, ...)" with its parameters in alphabetical order
+func DescribeGenerator(g Generator) string {
+  params := g.Parameters()
+  keys := make([]string, 0, len(params))
+  for key := range params {
+    keys = append(keys, key)
+  }
+  sort.Strings(keys)
+
+  parts := make([]string, len(keys))
+  for i, key := range keys {
+    parts[i] = key + "=" + strconv.FormatFloat(params[key], 'g', -1, 64)
+  }
+  return g.Name() + " (" + strings.Join(parts, ", ") + ")"
+}
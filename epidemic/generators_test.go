@@ -0,0 +1,64 @@
+package epidemic
+
+import (
+  "math/rand"
+  "testing"
+)
+
+//TestParseGeneratorErrors checks that parameters a generator cannot build a network from are rejected up front
+func TestParseGeneratorErrors(t *testing.T) {
+  invalid := []string{
+    "meyers:alpha=NaN", "meyers:alpha=-1", "meyers:kappa=0", "meyers:C=Inf", "meyers:directed=maybe",
+    "erdos-renyi:k=NaN", "er:k=-1", "er:k=1000",
+    "ws:k=3", "ws:k=4.5", "ws:k=1e20", "ws:beta=NaN", "ws:beta=2",
+    "ba:m=0", "ba:m=2.7", "ba:m=1e12", "ba:m=-1e300", "ba:m=Inf",
+    "lattice:width=-1", "lattice:width=1.5", "lattice:width=1001",
+    "er:size=10", "er:k", "torus",
+  }
+  for _, spec := range invalid {
+    if g, err := ParseGenerator(spec, 1000); err == nil {
+      t.Errorf("%q: got %+v, want an error", spec, g)
+    }
+  }
+
+  valid := map[string]Generator{
+    "meyers": MeyersGenerator{MeyersAlpha, MeyersKappa, 100, false},
+    "meyers:alpha=0,C=0.5,directed=true": MeyersGenerator{0, MeyersKappa, 0.5, true},
+    "er:k=2.5": ErdosRenyiGenerator{2.5},
+    "er:k=999": ErdosRenyiGenerator{999},
+    "ws:k=4.0,beta=1": WattsStrogatzGenerator{4, 1},
+    "ba:m=999": BarabasiAlbertGenerator{999},
+    "lattice:width=1000": LatticeGenerator{1000},
+  }
+  for spec, want := range valid {
+    g, err := ParseGenerator(spec, 1000)
+    if err != nil || g != want {
+      t.Errorf("%q: got %+v, %v, want %+v", spec, g, err, want)
+    }
+  }
+
+  //The defaults hold for populations smaller than they are
+  for _, spec := range []string{"er", "ws", "ba", "lattice"} {
+    if _, err := ParseGenerator(spec, 3); err != nil {
+      t.Errorf("%q in a population of 3: %v", spec, err)
+    }
+  }
+}
+
+//TestPowerLawSmallC checks that degrees are drawn even when the constant C puts the solution below 1, which used
+//to leave Newton's method stuck on log(k) of a negative k
+func TestPowerLawSmallC(t *testing.T) {
+  r := rand.New(rand.NewSource(1))
+  zeros := 0
+  for i := 0; i < 1000; i++ {
+    k := PowerLaw(r, MeyersAlpha, MeyersKappa, 0.01)
+    if k < 0 {
+      t.Fatalf("got negative degree %d", k)
+    } else if k == 0 {
+      zeros++
+    }
+  }
+  if zeros < 900 {
+    t.Errorf("got %d degrees of 0 out of 1000, want nearly all", zeros)
+  }
+}
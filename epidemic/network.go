@@ -0,0 +1,373 @@
+package epidemic
+
+import (
+  "math/rand"
+  "math"
+)
+
+//Node is one person in the population.
+//
+//Status is a single character: "S" susceptible, "V" vaccinated, "E" exposed (infected but not yet infectious),
+//"I" infected, "R" recovered and "D" dead. Vulnerability multiplies the probability of death (see GaussianVuln),
+//Susceptibility the probability of being infected by a contact and Infectiousness the probability of infecting a
+//contact (see Trait), and Connections are the people this node can infect.
+type Node struct {
+  ID int
+  Vulnerability float64
+  Status string
+  Connections []*Node
+  //timer counts down the timesteps left in the exposed "E" or infected "I" stage
+  timer int
+  //Age is the age group of the node, one of AgeGroups
+  Age string
+  //Infections counts how many times the node has been infected
+  Infections int
+  //Label is the id of the node in the file it was read from, empty for generated networks
+  Label string
+  //InfectedAt is the timestep of the latest infection of the node and InfectedBy the node that infected it, nil
+  //for patients zero. Both are only meaningful once Infections > 0.
+  InfectedAt int
+  InfectedBy *Node
+  //History records every infection of the node, see TransmissionTree
+  History []Transmission
+  //Vaccinated is true for nodes that received a vaccine, whether or not it protects them (see Vaccine). They keep
+  //it after their status changes, so that breakthrough infections can be told apart.
+  Vaccinated bool
+  //PriorImmunity is true for nodes read as recovered from a network file: they had the disease before the
+  //simulation started, which does not count as an infection (see CountStatuses)
+  PriorImmunity bool
+  Susceptibility float64
+  Infectiousness float64
+  //schedule holds the upcoming events of the node when the EventEngine steps the epidemic
+  schedule *schedule
+}
+
+//Network is a population of nodes. Node i of the network has ID i.
+type Network []*Node
+
+//AgeGroups lists the age groups nodes belong to, and AgeShares the fraction of the population in each of them
+//(based on U.S. census percentages of people under 18, 18 to 64 and 65 or older).
+var AgeGroups = []string{"child", "adult", "elderly"}
+var AgeShares = []float64{0.22, 0.61, 0.17}
+
+
+
+//IsAgeGroup returns true if group is one of AgeGroups
+func IsAgeGroup(group string) bool {
+  for i := range AgeGroups {
+    if AgeGroups[i] == group {
+      return true
+    }
+  }
+  return false
+}
+
+//DrawAgeGroup returns a random age group, following AgeShares
+func DrawAgeGroup(r *rand.Rand) string {
+  draw := r.Float64()
+  for i := range AgeGroups {
+    if draw < AgeShares[i] {
+      return AgeGroups[i]
+    }
+    draw -= AgeShares[i]
+  }
+  return AgeGroups[len(AgeGroups) - 1]
+}
+
+//IsIn returns true if a given integer appears in a target []int
+func IsIn(arr []int, k int) bool {
+  for i := range arr {
+    if arr[i] == k {
+      return true
+    }
+  }
+  return false
+}
+
+
+//MeanDegree is a network method that returns the mean degree of the network
+func (n Network) MeanDegree() float64 {
+  //calculate the mean degree of the network
+  k := 0
+  //sum the degree (length of connections slice) across all nodes
+  for i := range n {
+    k += len(n[i].Connections)
+  }
+  meanDegree := float64(k) / float64(len(n))
+  return meanDegree
+}
+
+//MeanResDegree is a network method that returns the mean original degree of the residual network
+func (n Network) MeanResDegree() float64 {
+  //Now we calculate the mean original degree of the residual nodes
+  kRes := 0
+  numRes := 0
+  //sum the degree of all residual nodes
+  for i := range n {
+    if n[i].Status == "S" {
+      numRes++
+      kRes += len(n[i].Connections)
+    }
+  }
+  meanResDegree := float64(kRes) / float64(numRes)
+  return meanResDegree
+}
+
+//ResResDegree is a network method that returns the mean residual degree of the residual network
+func (n Network) ResResDegree() float64 {
+  kRes := 0
+  numRes := 0
+  //sum the residual degree of all residual nodes
+  for i := range n {
+    if n[i].Status == "S" {
+      numRes++
+      for j := range n[i].Connections {
+        //Sum all the nodes connected to n[i] that are also residual nodes
+        if n[i].Connections[j].Status == "S" {
+          kRes++
+        }
+      }
+    }
+  }
+
+  resResDeg := float64(kRes) / float64(numRes)
+  return resResDeg
+}
+
+//MeanSquaredDegree is a network method that returns the mean squared-degree of the network
+func (n Network) MeanSquaredDegree() float64 {
+  k2 := 0
+  //sum the square of the degree (length of connections slice) across all nodes
+  for i := range n {
+    deg := len(n[i].Connections)
+    k2 += deg * deg
+  }
+  meanSquaredDegree := float64(k2) / float64(len(n))
+  return meanSquaredDegree
+}
+
+//NetworkFrailty takes an already infected network as an input and returns the frailty parameter
+func NetworkFrailty(n Network) float64 {
+
+  k := n.MeanDegree()
+  kr := n.MeanResDegree()
+
+  frailty := (k - kr) / k
+
+  return frailty
+}
+
+//NetworkInterference takes an already infected network as an input and returns the interference parameter
+func NetworkInterference(n Network) float64 {
+  k := n.MeanDegree()
+  kr := n.MeanResDegree()
+  krr := n.ResResDegree()
+
+  interf := (kr - krr) / k
+
+  return interf
+}
+
+
+//Sample from the power-law distribution, using Newton's method to solve for the transcendental equation
+func PowerLaw(r *rand.Rand, alpha, kappa, C float64) int {
+  //first, we sample from the uniform distribution over [0,1]
+  seed := r.Float64()
+
+  //From Meyers et al. We are given that an appropriate power-law equation for epidemiology problems is
+  //p_k = Ck^(-alpha) exp(-k/kappa)
+
+  //Using algebra, this can be rewritten as the following:
+  //(alpha)(kappa)log(k) + k - (kappa)(log(C/p_k)) = 0
+  //With p_k = seed
+
+
+  //We use Newton's method to solve for k (we have to approximate because log(k) + k is a transcendental function)
+  //Newton's method takes a target 'guess' (We will start with k_0 = 1) and iterates through subtracting f(k_n)/f'(k_n) to generate
+  //k_n+1. We set an arbitrary threshold of convergence delta, and when this threshold is reached, we return the closest integer. We don't need
+  //an extreme degree of accuracy since we are looking for integer degree outputs only
+
+  //So, initialize k to 1, and the convergence delta to 0.01
+  k := 1.0
+  delta := 0.0001
+
+  nextK := 0.0
+
+  //while not converged, implement Newton's method
+  for true {
+    //f(k) is simply the equation shown above.
+    fk := (alpha * kappa * math.Log(k)) + k - (kappa * math.Log((C / seed)))
+
+    //df(k), its derivative w.r.t. k, is equal to [(alpha)(kappa) + k]/k
+    dfk := ((alpha * kappa) + k) / k
+
+    nextK = k - (fk / dfk)
+    //A small C can put the solution below 1, where a step may overshoot past 0 and leave log(k) undefined.
+    //Halving the distance to 0 instead keeps k positive.
+    if nextK <= 0 {
+      nextK = k / 2
+    }
+    if math.Abs(nextK - k) <= delta {
+      k = nextK
+      break
+    }
+
+    k = nextK
+
+  }
+
+  return int(math.Round(k))
+
+}
+
+//InitializeNetwork takes an empty network and initializes nodes with Gaussian vulnerability multiplier, status of susceptible,
+//an empty list of connections (slice of pointers to nodes) and a random age group. Their susceptibility and infectiousness
+//are those of DefaultSusceptibility and DefaultInfectiousness, see Network.AssignTraits to draw others.
+func (n Network) InitializeNetwork(r *rand.Rand) {
+  for i := range n {
+    c := make([]*Node, 0)
+    vuln := GaussianVuln(r)
+    n[i] = &Node{ID: i, Vulnerability: vuln, Status: "S", Connections: c, Age: DrawAgeGroup(r), Susceptibility: vuln,
+      Infectiousness: 1}
+  }
+}
+
+//ConnectNetwork takes a network and connects it with undirected edges such that the degree of each node is sampled
+//from the power-law distribution outlined in Meyers et al. Every edge is reciprocal: if a is connected to b then b
+//is connected to a. See connectStubs for how the degrees are matched up.
+func (n Network) ConnectNetwork(r *rand.Rand, alpha, kappa, C float64) {
+  degrees := make([]int, len(n))
+  for i := range n {
+    //The degree of node n[i] is taken from the Power-Law distribution used in Meyers et al.
+    degrees[i] = PowerLaw(r, alpha, kappa, C)
+    if degrees[i] > len(n) - 1 {
+      degrees[i] = len(n) - 1
+    }
+  }
+
+  connectStubs(r, n, degrees)
+}
+
+//ConnectNetworkDirected is the original ConnectNetwork: every node draws its number of contacts from the power-law
+//distribution of Meyers et al. and points to that many random nodes, without them pointing back. The degree of a
+//node (len(Connections)) then only counts its own draws, not the nodes that drew it. It is kept to compare against
+//the undirected network and to replay old runs.
+func (n Network) ConnectNetworkDirected(r *rand.Rand, alpha, kappa, C float64) {
+  offsets, targets := meyersDirected(r, len(n), alpha, kappa, C)
+  for i := range n {
+    edges := make([]*Node, 0, offsets[i + 1] - offsets[i])
+    for _, target := range targets[offsets[i]:offsets[i + 1]] {
+      edges = append(edges, n[target])
+    }
+    n[i].Connections = edges
+  }
+}
+
+//meyersDirected draws the contacts of ConnectNetworkDirected for pop nodes, node i pointing to the nodes
+//targets[offsets[i]] to targets[offsets[i + 1] - 1], so that a CompactNetwork can be connected the same way
+func meyersDirected(r *rand.Rand, pop int, alpha, kappa, C float64) ([]int64, []int32) {
+  offsets := make([]int64, pop + 1)
+  targets := make([]int32, 0)
+
+  //connectedTo[j] is the last node that was connected to node j, so repeats are found without searching
+  connectedTo := make([]int, pop)
+  for j := range connectedTo {
+    connectedTo[j] = -1
+  }
+
+  for i := 0; i < pop; i++ {
+    //The degree of node i is taken from the Power-Law distribution used in Meyers et al.
+    c := PowerLaw(r, alpha, kappa, C)
+    //A node can connect to everyone but itself
+    if c > pop - 1 {
+      c = pop - 1
+    }
+
+    //Now connect node i to c random nodes in the network
+    for c > 0 {
+      target := r.Intn(pop)
+
+      //A node cannot point to itself, so continue generating until a non-self number is reached
+      //We also select a new target if the target is already connected to it
+      for (target == i || connectedTo[target] == i) {
+        target = r.Intn(pop)
+      }
+
+      targets = append(targets, int32(target))
+      connectedTo[target] = i
+      c--
+    }
+    offsets[i + 1] = int64(len(targets))
+  }
+  return offsets, targets
+}
+
+//Vaccinate takes a vaccination rate as a float64 input and vaccinates every Node in network n with probability (rate).
+//Only susceptible nodes are vaccinated, so nodes read from a network file as infected or recovered keep their status.
+func (n Network) Vaccinate(r *rand.Rand, rate float64) {
+  for i := range n {
+    vaccineChance := r.Float64()
+    if vaccineChance <= rate && n[i].Status == "S" {
+      n[i].Status = "V"
+      n[i].Vaccinated = true
+    }
+  }
+}
+
+//SeedInfection makes pZero randomly chosen susceptible nodes infectious with pathogen p, the patient(s) zero of the
+//epidemic. If there are fewer susceptible nodes than that, all of them are infected. Nodes that already have the
+//status "I" (read from a network file) become infectious as well, on top of the pZero new ones.
+func (n Network) SeedInfection(r *rand.Rand, pZero int, p Pathogen) {
+  for i := range n {
+    if n[i].Status == "I" && n[i].Infections == 0 {
+      p.MakeInfectious(r, n[i])
+      n[i].Infections++
+      n[i].History = append(n[i].History, Transmission{Infector: -1, Infectee: n[i].ID})
+    }
+  }
+
+  //Count the susceptible nodes once rather than for every patient zero, which would take a pass over the whole
+  //network each
+  susceptible := n.CountStatus("S")
+  for i := 0; i < pZero; i++ {
+    //If everyone is vaccinated or already infected, no one else is getting infected
+    if susceptible == 0 {
+      break
+    }
+
+    //Otherwise pick a random person from the network
+    patientZeroID := r.Intn(len(n))
+    //Prevent repeats
+    for n[patientZeroID].Status != "S" {
+      patientZeroID = r.Intn(len(n))
+    }
+
+    //Now set them to infected
+    p.MakeInfectious(r, n[patientZeroID])
+    n[patientZeroID].Infections++
+    n[patientZeroID].History = append(n[patientZeroID].History, Transmission{Infector: -1, Infectee: patientZeroID})
+    susceptible--
+  }
+}
+
+//CountStatus returns the number of nodes in the network with the given single character status
+func (n Network) CountStatus(status string) int {
+  count := 0
+  for i := range n {
+    if n[i].Status == status {
+      count++
+    }
+  }
+  return count
+}
+
+//IsInfected returns true if any node in a network is currently exposed or infected, false otherwise. This is how the
+//algorithm knows when to stop iterating.
+func (n Network) IsInfected() bool {
+  for i := range n {
+    if n[i].Status == "E" || n[i].Status == "I" {
+      return true
+    }
+  }
+  return false
+}
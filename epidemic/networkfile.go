@@ -0,0 +1,616 @@
+package epidemic
+
+import (
+  "bufio"
+  "encoding/csv"
+  "encoding/xml"
+  "fmt"
+  "io"
+  "math/rand"
+  "os"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "unicode"
+)
+
+//Contact networks can be read from four formats:
+//
+//  edgelist   one contact per line, the ids of two nodes separated by spaces, tabs or a comma. Further columns
+//             (weights, times) are ignored, a line with a single id adds a node without contacts, and blank
+//             lines and lines starting with '#' are skipped.
+//  adjacency  a CSV matrix. The first row holds the node ids after an empty (or any) first cell, every other row
+//             starts with the id of a node, in the same order, followed by 0 or an empty cell for no contact
+//             and any other number for a contact.
+//  graphml    GraphML, with node attributes declared as <key attr.name="vulnerability" for="node"> and so on.
+//  gml        GML, with node attributes as keys of the node, for example node [ id 3 age "child" ].
+//
+//Nodes may carry the attributes vulnerability (a number of at least 0), status (S, V, R or I, where I nodes are
+//the patients zero, see SeedInfection) and age (one of AgeGroups). Attributes that are not given are drawn as for
+//a generated network, and any other attributes are ignored. Contacts are undirected: an edge listed twice, a self
+//loop, or an edge to a node that was never declared (in the adjacency, GraphML and GML formats) is an error.
+//Directed GraphML and GML graphs and adjacency matrices may list a contact in both directions, which is read as
+//a single contact.
+
+//NetworkFileError describes a problem in a network file. Line is 0 for formats without line numbers (GraphML).
+type NetworkFileError struct {
+  Line int
+  Err error
+}
+
+func (e *NetworkFileError) Error() string {
+  if e.Line == 0 {
+    return e.Err.Error()
+  }
+  return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+//NodeRecord is one node of a contact network file. Label is its id in the file. A negative Vulnerability and an
+//empty Status or Age mean that attribute was not given.
+type NodeRecord struct {
+  Label string
+  Vulnerability float64
+  Status string
+  Age string
+}
+
+//ContactNetwork is a contact network read from a file: its nodes in the order they appear, and its edges as pairs
+//of indices into Nodes. It is a Generator, so it can be used wherever a generated network can, provided the
+//population is len(Nodes).
+type ContactNetwork struct {
+  Nodes []NodeRecord
+  Edges [][2]int
+}
+
+//Connect gives the nodes of n the labels and attributes read from the file, and connects them. n must have been
+//initialized with exactly len(c.Nodes) nodes.
+func (c ContactNetwork) Connect(r *rand.Rand, n Network) {
+  if len(n) != len(c.Nodes) {
+    panic(fmt.Sprintf("epidemic: contact network of %d nodes connecting a population of %d", len(c.Nodes), len(n)))
+  }
+
+  for i, rec := range c.Nodes {
+    n[i].Label = rec.Label
+    if rec.Vulnerability >= 0 {
+      n[i].Vulnerability = rec.Vulnerability
+    }
+    if rec.Status != "" {
+      n[i].Status = rec.Status
+      n[i].Vaccinated = rec.Status == "V"
+      n[i].PriorImmunity = rec.Status == "R"
+    }
+    if rec.Age != "" {
+      n[i].Age = rec.Age
+    }
+  }
+  for _, e := range c.Edges {
+    n.ConnectUndirected(e[0], e[1])
+  }
+}
+
+func (c ContactNetwork) Name() string {
+  return "file"
+}
+
+func (c ContactNetwork) Parameters() map[string]float64 {
+  return map[string]float64{"nodes": float64(len(c.Nodes)), "edges": float64(len(c.Edges))}
+}
+
+//ReadContactNetwork reads a contact network from a file in the given format: edgelist, adjacency, graphml or gml.
+//An empty format is guessed from the extension (.csv adjacency, .graphml, .gml, anything else an edge list).
+//Problems with the contents of the file wrap a *NetworkFileError.
+func ReadContactNetwork(filePath, format string) (ContactNetwork, error) {
+  if format == "" {
+    switch strings.ToLower(filepath.Ext(filePath)) {
+    case ".csv":
+      format = "adjacency"
+    case ".graphml", ".xml":
+      format = "graphml"
+    case ".gml":
+      format = "gml"
+    default:
+      format = "edgelist"
+    }
+  }
+
+  var parse func(io.Reader) (ContactNetwork, error)
+  switch format {
+  case "edgelist":
+    parse = ParseEdgeList
+  case "adjacency":
+    parse = ParseAdjacencyCSV
+  case "graphml":
+    parse = ParseGraphML
+  case "gml":
+    parse = ParseGML
+  default:
+    return ContactNetwork{}, fmt.Errorf("unknown network format %q, expected edgelist, adjacency, graphml or gml", format)
+  }
+
+  file, err := os.Open(filePath)
+  if err != nil {
+    return ContactNetwork{}, fmt.Errorf("reading network file: %w", err)
+  }
+  defer file.Close()
+
+  c, err := parse(file)
+  if err != nil {
+    return ContactNetwork{}, fmt.Errorf("reading %s: %w", filePath, err)
+  }
+  return c, nil
+}
+
+//contactBuilder collects the nodes and edges of a contact network while a file is read
+type contactBuilder struct {
+  c ContactNetwork
+  index map[string]int
+  //edges holds every edge in the direction it was first listed
+  edges map[[2]int]bool
+}
+
+func newContactBuilder() *contactBuilder {
+  return &contactBuilder{ContactNetwork{make([]NodeRecord, 0), make([][2]int, 0)}, make(map[string]int), make(map[[2]int]bool)}
+}
+
+//declare adds a node, which must not have been declared before
+func (b *contactBuilder) declare(label string) (int, error) {
+  if label == "" {
+    return 0, fmt.Errorf("node without an id")
+  } else if _, ok := b.index[label]; ok {
+    return 0, fmt.Errorf("node %q is declared twice", label)
+  }
+  b.index[label] = len(b.c.Nodes)
+  b.c.Nodes = append(b.c.Nodes, NodeRecord{label, -1, "", ""})
+  return len(b.c.Nodes) - 1, nil
+}
+
+//node returns the index of a node, declaring it if it is new
+func (b *contactBuilder) node(label string) int {
+  if i, ok := b.index[label]; ok {
+    return i
+  }
+  i, _ := b.declare(label)
+  return i
+}
+
+//connect adds the edge between the declared nodes source and target. If directed is true, the edge in the other
+//direction may also be listed and is merged with this one.
+func (b *contactBuilder) connect(source, target string, directed bool) error {
+  s, okS := b.index[source]
+  t, okT := b.index[target]
+  if okS == false {
+    return fmt.Errorf("edge %s-%s: node %q is not declared", source, target, source)
+  } else if okT == false {
+    return fmt.Errorf("edge %s-%s: node %q is not declared", source, target, target)
+  }
+  return b.connectIndices(s, t, directed)
+}
+
+func (b *contactBuilder) connectIndices(s, t int, directed bool) error {
+  source := b.c.Nodes[s].Label
+  target := b.c.Nodes[t].Label
+  if s == t {
+    return fmt.Errorf("edge %s-%s connects a node to itself", source, target)
+  } else if b.edges[[2]int{s, t}] {
+    return fmt.Errorf("edge %s-%s is listed twice", source, target)
+  } else if b.edges[[2]int{t, s}] {
+    if directed {
+      return nil
+    }
+    return fmt.Errorf("edge %s-%s is listed twice (as %s-%s)", source, target, target, source)
+  }
+  b.edges[[2]int{s, t}] = true
+  b.c.Edges = append(b.c.Edges, [2]int{s, t})
+  return nil
+}
+
+//setAttribute sets a node attribute read from a file. Unknown attributes and empty values are ignored.
+func (b *contactBuilder) setAttribute(i int, key, value string) error {
+  value = strings.TrimSpace(value)
+  if value == "" {
+    return nil
+  }
+  rec := &b.c.Nodes[i]
+  switch strings.ToLower(key) {
+  case "vulnerability":
+    v, err := strconv.ParseFloat(value, 64)
+    if err != nil || v < 0 {
+      return fmt.Errorf("node %q: vulnerability %q must be a number of at least 0", rec.Label, value)
+    }
+    rec.Vulnerability = v
+  case "status":
+    status := strings.ToUpper(value)
+    if status != "S" && status != "V" && status != "R" && status != "I" {
+      return fmt.Errorf("node %q: status %q must be S, V, R or I", rec.Label, value)
+    }
+    rec.Status = status
+  case "age":
+    if IsAgeGroup(value) == false {
+      return fmt.Errorf("node %q: age %q must be one of %s", rec.Label, value, strings.Join(AgeGroups, ", "))
+    }
+    rec.Age = value
+  }
+  return nil
+}
+
+//ParseEdgeList reads a contact network in the edge list format
+func ParseEdgeList(reader io.Reader) (ContactNetwork, error) {
+  b := newContactBuilder()
+  scanner := bufio.NewScanner(reader)
+  lineNum := 0
+  for scanner.Scan() {
+    lineNum++
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" || strings.HasPrefix(line, "#") {
+      continue
+    }
+
+    fields := strings.FieldsFunc(line, func(c rune) bool {
+      return c == ',' || unicode.IsSpace(c)
+    })
+    source := b.node(fields[0])
+    if len(fields) == 1 {
+      continue
+    }
+    if err := b.connectIndices(source, b.node(fields[1]), false); err != nil {
+      return ContactNetwork{}, &NetworkFileError{lineNum, err}
+    }
+  }
+  if err := scanner.Err(); err != nil {
+    return ContactNetwork{}, err
+  }
+  return b.c, nil
+}
+
+//ParseAdjacencyCSV reads a contact network in the adjacency matrix format
+func ParseAdjacencyCSV(reader io.Reader) (ContactNetwork, error) {
+  rows, err := csv.NewReader(reader).ReadAll()
+  if err != nil {
+    return ContactNetwork{}, err
+  } else if len(rows) == 0 {
+    return ContactNetwork{}, &NetworkFileError{1, fmt.Errorf("missing the header row of node ids")}
+  }
+
+  b := newContactBuilder()
+  for _, label := range rows[0][1:] {
+    if _, err := b.declare(strings.TrimSpace(label)); err != nil {
+      return ContactNetwork{}, &NetworkFileError{1, err}
+    }
+  }
+  if len(rows) - 1 != len(b.c.Nodes) {
+    return ContactNetwork{}, &NetworkFileError{len(rows), fmt.Errorf("%d rows for %d nodes", len(rows) - 1, len(b.c.Nodes))}
+  }
+
+  for i, row := range rows[1:] {
+    label := strings.TrimSpace(row[0])
+    if label != b.c.Nodes[i].Label {
+      return ContactNetwork{}, &NetworkFileError{i + 2, fmt.Errorf("row of node %q where node %q was expected", label, b.c.Nodes[i].Label)}
+    }
+    for j, cell := range row[1:] {
+      cell = strings.TrimSpace(cell)
+      if cell == "" {
+        continue
+      }
+      w, err := strconv.ParseFloat(cell, 64)
+      if err != nil {
+        return ContactNetwork{}, &NetworkFileError{i + 2, fmt.Errorf("unable to parse %q in the column of node %q", cell, b.c.Nodes[j].Label)}
+      } else if w == 0 {
+        continue
+      }
+      if err := b.connectIndices(i, j, true); err != nil {
+        return ContactNetwork{}, &NetworkFileError{i + 2, err}
+      }
+    }
+  }
+  return b.c, nil
+}
+
+//The parts of GraphML the simulator reads
+type graphmlFile struct {
+  Keys []graphmlKey `xml:"key"`
+  Graph graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+  ID string `xml:"id,attr"`
+  For string `xml:"for,attr"`
+  Name string `xml:"attr.name,attr"`
+  Default *string `xml:"default"`
+}
+
+type graphmlGraph struct {
+  EdgeDefault string `xml:"edgedefault,attr"`
+  Nodes []graphmlNode `xml:"node"`
+  Edges []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+  ID string `xml:"id,attr"`
+  Data []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+  Key string `xml:"key,attr"`
+  Value string `xml:",chardata"`
+}
+
+type graphmlEdge struct {
+  Source string `xml:"source,attr"`
+  Target string `xml:"target,attr"`
+  Directed string `xml:"directed,attr"`
+}
+
+//ParseGraphML reads a contact network in the GraphML format
+func ParseGraphML(reader io.Reader) (ContactNetwork, error) {
+  var doc graphmlFile
+  if err := xml.NewDecoder(reader).Decode(&doc); err != nil {
+    return ContactNetwork{}, err
+  }
+
+  //Node attributes are declared by key, which maps the id used by <data> to the attribute name
+  names := make(map[string]string)
+  for _, key := range doc.Keys {
+    if key.For == "node" || key.For == "all" {
+      names[key.ID] = key.Name
+    }
+  }
+
+  b := newContactBuilder()
+  for _, node := range doc.Graph.Nodes {
+    i, err := b.declare(node.ID)
+    if err != nil {
+      return ContactNetwork{}, &NetworkFileError{0, err}
+    }
+
+    values := make(map[string]string)
+    for _, key := range doc.Keys {
+      if names[key.ID] != "" && key.Default != nil {
+        values[key.ID] = *key.Default
+      }
+    }
+    for _, data := range node.Data {
+      values[data.Key] = data.Value
+    }
+    for key, value := range values {
+      if err := b.setAttribute(i, names[key], value); err != nil {
+        return ContactNetwork{}, &NetworkFileError{0, err}
+      }
+    }
+  }
+
+  for _, edge := range doc.Graph.Edges {
+    directed := doc.Graph.EdgeDefault == "directed"
+    if edge.Directed != "" {
+      directed = edge.Directed == "true"
+    }
+    if err := b.connect(edge.Source, edge.Target, directed); err != nil {
+      return ContactNetwork{}, &NetworkFileError{0, err}
+    }
+  }
+  return b.c, nil
+}
+
+//gmlPair is one "key value" pair of a GML file. Value is either a string or, for bracketed lists, []gmlPair.
+type gmlPair struct {
+  Key string
+  Value interface{}
+  Line int
+}
+
+//ParseGML reads a contact network in the GML format
+func ParseGML(reader io.Reader) (ContactNetwork, error) {
+  data, err := io.ReadAll(reader)
+  if err != nil {
+    return ContactNetwork{}, err
+  }
+  tokens, lines, err := tokenizeGML(string(data))
+  if err != nil {
+    return ContactNetwork{}, err
+  }
+  pos := 0
+  pairs, err := parseGMLList(tokens, lines, &pos, false)
+  if err != nil {
+    return ContactNetwork{}, err
+  }
+
+  var graph []gmlPair
+  for _, pair := range pairs {
+    if list, ok := pair.Value.([]gmlPair); ok && pair.Key == "graph" {
+      graph = list
+    }
+  }
+  if graph == nil {
+    return ContactNetwork{}, &NetworkFileError{1, fmt.Errorf("no graph [ ... ] found")}
+  }
+
+  b := newContactBuilder()
+  directed := false
+  for _, pair := range graph {
+    if pair.Key == "directed" {
+      directed = pair.Value == "1"
+    }
+  }
+
+  //Every node has to be declared before the edges are read, wherever they are in the file
+  for _, pair := range graph {
+    node, ok := pair.Value.([]gmlPair)
+    if pair.Key != "node" || ok == false {
+      continue
+    }
+    id, found := gmlValue(node, "id")
+    if found == false {
+      return ContactNetwork{}, &NetworkFileError{pair.Line, fmt.Errorf("node without an id")}
+    }
+    i, err := b.declare(id)
+    if err != nil {
+      return ContactNetwork{}, &NetworkFileError{pair.Line, err}
+    }
+    for _, attr := range node {
+      if value, ok := attr.Value.(string); ok && attr.Key != "id" {
+        if err := b.setAttribute(i, attr.Key, value); err != nil {
+          return ContactNetwork{}, &NetworkFileError{attr.Line, err}
+        }
+      }
+    }
+  }
+
+  for _, pair := range graph {
+    edge, ok := pair.Value.([]gmlPair)
+    if pair.Key != "edge" || ok == false {
+      continue
+    }
+    source, okS := gmlValue(edge, "source")
+    target, okT := gmlValue(edge, "target")
+    if okS == false || okT == false {
+      return ContactNetwork{}, &NetworkFileError{pair.Line, fmt.Errorf("edge without a source and target")}
+    }
+    if err := b.connect(source, target, directed); err != nil {
+      return ContactNetwork{}, &NetworkFileError{pair.Line, err}
+    }
+  }
+  return b.c, nil
+}
+
+//gmlValue returns the value of the first plain (not list) key in a GML list
+func gmlValue(pairs []gmlPair, key string) (string, bool) {
+  for _, pair := range pairs {
+    if value, ok := pair.Value.(string); ok && pair.Key == key {
+      return value, true
+    }
+  }
+  return "", false
+}
+
+//tokenizeGML splits GML into keys, values, "[" and "]", returning the line every token is on. Quotes are removed
+//from strings, and lines starting with '#' are comments.
+func tokenizeGML(s string) ([]string, []int, error) {
+  tokens := make([]string, 0)
+  lines := make([]int, 0)
+  line := 1
+  i := 0
+  for i < len(s) {
+    c := s[i]
+    switch {
+    case c == '\n':
+      line++
+      i++
+    case c == ' ' || c == '\t' || c == '\r':
+      i++
+    case c == '#':
+      for i < len(s) && s[i] != '\n' {
+        i++
+      }
+    case c == '[' || c == ']':
+      tokens = append(tokens, string(c))
+      lines = append(lines, line)
+      i++
+    case c == '"':
+      end := strings.IndexByte(s[i + 1:], '"')
+      if end < 0 {
+        return nil, nil, &NetworkFileError{line, fmt.Errorf("unterminated string")}
+      }
+      value := s[i + 1 : i + 1 + end]
+      tokens = append(tokens, "\"" + value)
+      lines = append(lines, line)
+      line += strings.Count(value, "\n")
+      i += end + 2
+    default:
+      start := i
+      for i < len(s) && strings.IndexByte(" \t\r\n[]\"", s[i]) < 0 {
+        i++
+      }
+      tokens = append(tokens, s[start:i])
+      lines = append(lines, line)
+    }
+  }
+  return tokens, lines, nil
+}
+
+//parseGMLList reads key value pairs from tokens starting at *pos, up to the closing "]" if nested is true or the
+//end of the file otherwise
+func parseGMLList(tokens []string, lines []int, pos *int, nested bool) ([]gmlPair, error) {
+  pairs := make([]gmlPair, 0)
+  for *pos < len(tokens) {
+    key := tokens[*pos]
+    line := lines[*pos]
+    *pos++
+    if key == "]" {
+      if nested {
+        return pairs, nil
+      }
+      return nil, &NetworkFileError{line, fmt.Errorf("unexpected ]")}
+    } else if key == "[" || strings.HasPrefix(key, "\"") {
+      return nil, &NetworkFileError{line, fmt.Errorf("expected a key, found %q", strings.TrimPrefix(key, "\""))}
+    }
+
+    if *pos >= len(tokens) {
+      return nil, &NetworkFileError{line, fmt.Errorf("key %s has no value", key)}
+    }
+    value := tokens[*pos]
+    *pos++
+    if value == "[" {
+      list, err := parseGMLList(tokens, lines, pos, true)
+      if err != nil {
+        return nil, err
+      }
+      pairs = append(pairs, gmlPair{key, list, line})
+    } else if value == "]" {
+      return nil, &NetworkFileError{line, fmt.Errorf("key %s has no value", key)}
+    } else {
+      pairs = append(pairs, gmlPair{key, strings.TrimPrefix(value, "\""), line})
+    }
+  }
+
+  if nested {
+    return nil, &NetworkFileError{lines[len(lines) - 1], fmt.Errorf("missing ]")}
+  }
+  return pairs, nil
+}
+
+//ReadNodeAttributes reads node attributes from a CSV file into c, for formats that cannot hold them such as edge
+//lists. The header names the columns: id and any of vulnerability, status and age. Every id must be a node of c.
+func ReadNodeAttributes(c ContactNetwork, filePath string) error {
+  file, err := os.Open(filePath)
+  if err != nil {
+    return fmt.Errorf("reading node attributes: %w", err)
+  }
+  defer file.Close()
+
+  rows, err := csv.NewReader(file).ReadAll()
+  if err != nil {
+    return fmt.Errorf("reading %s: %w", filePath, err)
+  } else if len(rows) == 0 {
+    return nil
+  }
+
+  idCol := -1
+  for j, name := range rows[0] {
+    if strings.TrimSpace(name) == "id" {
+      idCol = j
+    }
+  }
+  if idCol < 0 {
+    return fmt.Errorf("reading %s: %w", filePath, &NetworkFileError{1, fmt.Errorf("no id column")})
+  }
+
+  //Reuse the builder's validation on the nodes of c, which share their backing array
+  b := &contactBuilder{c, make(map[string]int), nil}
+  for i, rec := range c.Nodes {
+    b.index[rec.Label] = i
+  }
+  for i, row := range rows[1:] {
+    label := strings.TrimSpace(row[idCol])
+    node, ok := b.index[label]
+    if ok == false {
+      return fmt.Errorf("reading %s: %w", filePath, &NetworkFileError{i + 2, fmt.Errorf("node %q is not in the network", label)})
+    }
+    for j, value := range row {
+      if j != idCol {
+        if err := b.setAttribute(node, strings.TrimSpace(rows[0][j]), value); err != nil {
+          return fmt.Errorf("reading %s: %w", filePath, &NetworkFileError{i + 2, err})
+        }
+      }
+    }
+  }
+  return nil
+}
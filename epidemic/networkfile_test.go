@@ -0,0 +1,203 @@
+package epidemic
+
+import (
+  "errors"
+  "io"
+  "math/rand"
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+//networkParsers maps every network file format to its reader
+var networkParsers = map[string]func(io.Reader) (ContactNetwork, error){
+  "edgelist": ParseEdgeList,
+  "adjacency": ParseAdjacencyCSV,
+  "graphml": ParseGraphML,
+  "gml": ParseGML,
+}
+
+//plainRecord returns the record of a node read without attributes
+func plainRecord(label string) NodeRecord {
+  return NodeRecord{label, -1, "", ""}
+}
+
+const testGraphML = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="d0" for="node" attr.name="vulnerability" attr.type="double"/>
+  <key id="d1" for="node" attr.name="status" attr.type="string"/>
+  <key id="d2" for="node" attr.name="age" attr.type="string"><default>adult</default></key>
+  <key id="d3" for="node" attr.name="colour" attr.type="string"/>
+  <key id="w" for="edge" attr.name="weight" attr.type="double"/>
+  <graph id="G" edgedefault="undirected">
+    <node id="n7"><data key="d0">1.5</data><data key="d1">i</data></node>
+    <node id="n3"><data key="d2">child</data><data key="d3">red</data></node>
+    <node id="n5"><data key="d1">R</data></node>
+    <edge source="n7" target="n3"><data key="w">2</data></edge>
+    <edge source="n5" target="n3"/>
+  </graph>
+</graphml>`
+
+const testGML = `# contacts of a small class
+graph [
+  directed 0
+  edge [ source 7 target 3 ]
+  node [ id 7 label "teacher" vulnerability 1.5 status "I" age "adult" ]
+  node [ id 3 age "child" ]
+  node [
+    id 5
+    status "V"
+  ]
+  edge [ source 5 target 3 weight 0.5 ]
+]`
+
+//TestParseContactNetwork checks that every format keeps the ids of the nodes in the order they are declared, reads
+//their attributes and ignores anything else
+func TestParseContactNetwork(t *testing.T) {
+  tests := []struct {
+    format string
+    file string
+    want ContactNetwork
+  }{
+    {"edgelist", "# a comment\n7 3\n\n3,5 0.5 12\n  9\n", ContactNetwork{
+      []NodeRecord{plainRecord("7"), plainRecord("3"), plainRecord("5"), plainRecord("9")},
+      [][2]int{{0, 1}, {1, 2}}}},
+    {"adjacency", ",7,3,5\n7,0,1,\n3,1,0,2.5\n5,,1,0\n", ContactNetwork{
+      []NodeRecord{plainRecord("7"), plainRecord("3"), plainRecord("5")},
+      [][2]int{{0, 1}, {1, 2}}}},
+    {"graphml", testGraphML, ContactNetwork{
+      []NodeRecord{{"n7", 1.5, "I", "adult"}, {"n3", -1, "", "child"}, {"n5", -1, "R", "adult"}},
+      [][2]int{{0, 1}, {2, 1}}}},
+    {"gml", testGML, ContactNetwork{
+      []NodeRecord{{"7", 1.5, "I", "adult"}, {"3", -1, "", "child"}, {"5", -1, "V", ""}},
+      [][2]int{{0, 1}, {2, 1}}}},
+  }
+
+  for _, test := range tests {
+    got, err := networkParsers[test.format](strings.NewReader(test.file))
+    if err != nil {
+      t.Errorf("%s: %v", test.format, err)
+    } else if reflect.DeepEqual(got, test.want) == false {
+      t.Errorf("%s: got %+v, want %+v", test.format, got, test.want)
+    }
+  }
+}
+
+//TestParseContactNetworkErrors checks that every format rejects broken files with the line at fault, 0 for GraphML
+func TestParseContactNetworkErrors(t *testing.T) {
+  tests := []struct {
+    name string
+    format string
+    file string
+    line int
+    //message is part of the error expected
+    message string
+  }{
+    {"self loop", "edgelist", "1 2\n2 2\n", 2, "connects a node to itself"},
+    {"edge listed twice", "edgelist", "1 2\n2 3\n1 2\n", 3, "listed twice"},
+    {"edge listed in both directions", "edgelist", "1 2\n\n2 1\n", 3, "listed twice (as 1-2)"},
+    {"empty matrix", "adjacency", "", 1, "missing the header row"},
+    {"node declared twice", "adjacency", ",a,a\na,0,1\na,1,0\n", 1, "declared twice"},
+    {"missing row", "adjacency", ",a,b\na,0,1\n", 2, "1 rows for 2 nodes"},
+    {"rows out of order", "adjacency", ",a,b\nb,0,1\na,1,0\n", 2, "row of node \"b\" where node \"a\""},
+    {"bad cell", "adjacency", ",a,b\na,0,x\nb,1,0\n", 2, "unable to parse \"x\""},
+    {"self contact", "adjacency", ",a,b\na,1,0\nb,0,0\n", 2, "connects a node to itself"},
+    {"dangling edge", "graphml", `<graphml><graph edgedefault="undirected"><node id="a"/><node id="b"/>` +
+      `<edge source="a" target="c"/></graph></graphml>`, 0, "node \"c\" is not declared"},
+    {"node without an id", "graphml", `<graphml><graph><node/></graph></graphml>`, 0, "node without an id"},
+    {"undirected edge listed twice", "graphml", `<graphml><graph edgedefault="undirected"><node id="a"/>` +
+      `<node id="b"/><edge source="a" target="b"/><edge source="b" target="a"/></graph></graphml>`, 0, "listed twice"},
+    {"directed edge listed twice", "graphml", `<graphml><graph edgedefault="directed"><node id="a"/>` +
+      `<node id="b"/><edge source="a" target="b"/><edge source="a" target="b"/></graph></graphml>`, 0, "listed twice"},
+    {"bad status", "graphml", `<graphml><key id="s" for="node" attr.name="status"/><graph><node id="a">` +
+      `<data key="s">D</data></node></graph></graphml>`, 0, "status \"D\" must be S, V, R or I"},
+    {"no graph", "gml", "creator \"me\"\n", 1, "no graph"},
+    {"dangling edge", "gml", "graph [\n node [ id 1 ]\n edge [ source 1 target 2 ]\n]\n", 3, "node \"2\" is not declared"},
+    {"node declared twice", "gml", "graph [\n node [ id 1 ]\n node [ id 1 ]\n]\n", 3, "declared twice"},
+    {"node without an id", "gml", "graph [\n node [ label \"x\" ]\n]\n", 2, "node without an id"},
+    {"edge without a target", "gml", "graph [\n node [ id 1 ]\n edge [ source 1 ]\n]\n", 3, "without a source and target"},
+    {"bad vulnerability", "gml", "graph [\n node [\n  id 1\n  vulnerability -2\n ]\n]\n", 4, "at least 0"},
+    {"bad age", "gml", "graph [\n node [ id 1 age \"teen\" ]\n]\n", 2, "age \"teen\" must be one of"},
+    {"unterminated string", "gml", "graph [\n node [ id 1 label \"x ]\n]\n", 2, "unterminated string"},
+    {"missing ]", "gml", "graph [\n node [ id 1 ]\n", 2, "missing ]"},
+    {"stray ]", "gml", "graph [ ]\n]\n", 2, "unexpected ]"},
+  }
+
+  for _, test := range tests {
+    _, err := networkParsers[test.format](strings.NewReader(test.file))
+    var fileErr *NetworkFileError
+    if errors.As(err, &fileErr) == false {
+      t.Errorf("%s %s: got error %v, want a *NetworkFileError", test.format, test.name, err)
+      continue
+    }
+    if fileErr.Line != test.line || strings.Contains(err.Error(), test.message) == false {
+      t.Errorf("%s %s: got %q on line %d, want %q on line %d", test.format, test.name, err, fileErr.Line, test.message,
+        test.line)
+    }
+  }
+}
+
+//TestReadNodeAttributes checks that attributes read from a CSV file are given to the nodes of an edge list by id,
+//in any order of the columns, and that ids missing from the network are rejected
+func TestReadNodeAttributes(t *testing.T) {
+  c, err := ParseEdgeList(strings.NewReader("a b\nb c\n"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  dir := t.TempDir()
+  attributes := filepath.Join(dir, "attributes.csv")
+  if err := os.WriteFile(attributes, []byte("age,id,status,shoe size\nelderly,c,r,44\nchild,a,,\n"), 0644); err != nil {
+    t.Fatal(err)
+  }
+  if err := ReadNodeAttributes(c, attributes); err != nil {
+    t.Fatal(err)
+  }
+  want := []NodeRecord{{"a", -1, "", "child"}, plainRecord("b"), {"c", -1, "R", "elderly"}}
+  if reflect.DeepEqual(c.Nodes, want) == false {
+    t.Errorf("got nodes %+v, want %+v", c.Nodes, want)
+  }
+
+  unknown := filepath.Join(dir, "unknown.csv")
+  if err := os.WriteFile(unknown, []byte("id,age\na,adult\nd,child\n"), 0644); err != nil {
+    t.Fatal(err)
+  }
+  err = ReadNodeAttributes(c, unknown)
+  var fileErr *NetworkFileError
+  if errors.As(err, &fileErr) == false || fileErr.Line != 3 {
+    t.Errorf("unknown id: got error %v, want one on line 3", err)
+  }
+}
+
+//TestContactNetworkConnect checks that the nodes of a network read from a file keep their ids as labels and their
+//attributes, and that recovered nodes are immune without counting as infected
+func TestContactNetworkConnect(t *testing.T) {
+  c, err := ParseGraphML(strings.NewReader(testGraphML))
+  if err != nil {
+    t.Fatal(err)
+  }
+  r := rand.New(rand.NewSource(1))
+  n := BuildNetworkWith(r, len(c.Nodes), c)
+
+  for i, rec := range c.Nodes {
+    if n[i].Label != rec.Label {
+      t.Errorf("node %d labelled %q, want %q", i, n[i].Label, rec.Label)
+    }
+  }
+  if n[0].Vulnerability != 1.5 || n[0].Status != "I" || n[1].Age != "child" || n[2].Age != "adult" {
+    t.Errorf("attributes not kept: %+v, %+v, %+v", *n[0], *n[1], *n[2])
+  }
+  if n.MeanDegree() != 4.0 / 3.0 {
+    t.Errorf("mean degree %g, want %g", n.MeanDegree(), 4.0 / 3.0)
+  }
+  if n[2].Status != "R" || n[2].PriorImmunity == false || n[2].Infections != 0 {
+    t.Errorf("recovered node read as status %s, prior immunity %t and %d infections", n[2].Status, n[2].PriorImmunity,
+      n[2].Infections)
+  }
+
+  counts := CountStatuses(n)
+  if counts["prior immune"] != 1 || counts["ever infected"] != 0 {
+    t.Errorf("counted %d prior immune and %d ever infected, want 1 and 0", counts["prior immune"], counts["ever infected"])
+  }
+}
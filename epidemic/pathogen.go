@@ -0,0 +1,74 @@
+package epidemic
+
+import (
+  "math/rand"
+)
+
+//Pathogen is a disease spreading through a network. Ro is its base reproductive ratio and Lethality the
+//probability that an infected person dies of it.
+type Pathogen struct {
+  Name  string `json:"name"`
+  Ro  float64 `json:"Ro"`
+  Lethality float64 `json:"lethality"`
+  //Latent and Infectious are how long a node stays exposed "E" and infected "I"
+  Latent Period `json:"latent"`
+  Infectious Period `json:"infectious"`
+  //Waning is the probability that a recovered node loses its immunity every timestep
+  Waning float64 `json:"waning"`
+  //AgeLethality multiplies the lethality for nodes in the age groups it contains
+  AgeLethality map[string]float64 `json:"age_lethality,omitempty"`
+  //Vaccine is how well vaccination protects against the pathogen
+  Vaccine Vaccine `json:"vaccine"`
+}
+
+//NewPathogen returns a pathogen with no latent period that is infectious for exactly one timestep and gives lasting
+//immunity, with a vaccine that protects everyone who gets it, which is how every pathogen behaved before these
+//could be set.
+func NewPathogen(name string, Ro, lethality float64) Pathogen {
+  return Pathogen{name, Ro, lethality, Period{"fixed", 0}, Period{"fixed", 1}, 0, nil, PerfectVaccine()}
+}
+
+//LethalityFor returns the probability of death for an infected node, taking its vulnerability and age group into
+//account and, for breakthrough infections of vaccinated nodes, the lethality multiplier of the vaccine
+func (p Pathogen) LethalityFor(node *Node) float64 {
+  lethality := p.Lethality * node.Vulnerability
+  if multiplier, ok := p.AgeLethality[node.Age]; ok {
+    lethality *= multiplier
+  }
+  if node.Vaccinated {
+    lethality *= p.Vaccine.Lethality
+  }
+  return lethality
+}
+
+//Infect moves a node into the exposed stage "E" for a latent period drawn from the pathogen, or straight into
+//the infectious stage "I" if that period is 0 timesteps long. The node remembers it was infected by infector at
+//timestep epoch.
+func (p Pathogen) Infect(r *rand.Rand, node, infector *Node, epoch int) {
+  node.infectedBy(infector, epoch, float64(epoch))
+  latent := p.Latent.Draw(r)
+  if latent > 0 {
+    node.Status = "E"
+    node.timer = latent
+  } else {
+    p.MakeInfectious(r, node)
+  }
+}
+
+//infectedBy records that node was infected by infector at the given time, during timestep epoch
+func (node *Node) infectedBy(infector *Node, epoch int, time float64) {
+  node.Infections++
+  node.InfectedAt = epoch
+  node.InfectedBy = infector
+  node.History = append(node.History, Transmission{Infector: infector.ID, Infectee: node.ID, Epoch: epoch, Time: time})
+}
+
+//MakeInfectious moves a node into the infectious stage "I" for an infectious period drawn from the pathogen.
+//Every infected node is infectious for at least one timestep.
+func (p Pathogen) MakeInfectious(r *rand.Rand, node *Node) {
+  node.Status = "I"
+  node.timer = p.Infectious.Draw(r)
+  if node.timer < 1 {
+    node.timer = 1
+  }
+}
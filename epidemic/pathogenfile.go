@@ -0,0 +1,238 @@
+package epidemic
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "math"
+  "os"
+  "strconv"
+  "strings"
+)
+
+//PathogenHeader is the first line of a version 2 .PATHOGEN file. Files without a header are read as the original
+//three line format: the name, Ro and mortality rate, one per line.
+const PathogenHeader = "PATHOGEN 2"
+
+//A version 2 .PATHOGEN file names every field, one "key = value" pair per line. Blank lines and lines starting
+//with '#' are ignored. name, Ro and lethality are required, everything else is optional:
+//
+//  PATHOGEN 2
+//  name = measles
+//  Ro = 15
+//  lethality = 0.03
+//  latent = fixed:10
+//  infectious = fixed:8
+//  waning = 0.001
+//  age.elderly = 3
+//  vaccine.efficacy = 0.97
+//  vaccine.mode = all-or-nothing
+//  vaccine.lethality = 0.5
+//  vaccine.waning = 0.002
+//
+//waning is the probability that a recovered person loses their immunity every timestep, and age.child, age.adult
+//and age.elderly multiply the mortality rate of people in that age group. vaccine.efficacy (default 1),
+//vaccine.mode (all-or-nothing or leaky) and vaccine.lethality (the multiplier of the mortality rate of breakthrough
+//infections, default 1) describe the vaccine, see Vaccine. vaccine.waning is the probability that a vaccinated
+//person loses their protection every timestep.
+
+//PathogenError describes a problem with one field of a .PATHOGEN file
+type PathogenError struct {
+  Line int
+  Field string
+  Err error
+}
+
+func (e *PathogenError) Error() string {
+  return fmt.Sprintf("line %d: %s: %v", e.Line, e.Field, e.Err)
+}
+
+//ReadPathogenFromFile reads a pathogen from the .PATHOGEN file specified, in either the versioned or the original
+//format. Problems with the contents of the file wrap a *PathogenError saying which line and field failed.
+func ReadPathogenFromFile(filePath string) (Pathogen, error) {
+  file, errF := os.Open(filePath)
+
+  if errF != nil {
+    return Pathogen{}, fmt.Errorf("reading .PATHOGEN file: %w", errF)
+  }
+
+  defer file.Close()
+
+  p, err := ParsePathogen(file)
+  if err != nil {
+    return Pathogen{}, fmt.Errorf("reading %s: %w", filePath, err)
+  }
+
+  return p, nil
+}
+
+//ParsePathogen reads a pathogen in either .PATHOGEN format. Every problem is reported as a *PathogenError.
+func ParsePathogen(reader io.Reader) (Pathogen, error) {
+  scanner := bufio.NewScanner(reader)
+  lines := make([]string, 0)
+  for scanner.Scan() {
+    lines = append(lines, strings.TrimSpace(scanner.Text()))
+  }
+  if err := scanner.Err(); err != nil {
+    return Pathogen{}, err
+  }
+
+  //Skip to the first line with something on it to look for the header
+  first := 0
+  for first < len(lines) && lines[first] == "" {
+    first++
+  }
+
+  if first < len(lines) && lines[first] == PathogenHeader {
+    return parseVersionedPathogen(lines, first + 1)
+  } else if first < len(lines) && strings.HasPrefix(lines[first], "PATHOGEN ") {
+    return Pathogen{}, &PathogenError{first + 1, "version", fmt.Errorf("unsupported version %q, expected %q", lines[first], PathogenHeader)}
+  }
+
+  return parseLegacyPathogen(lines)
+}
+
+//parseLegacyPathogen reads the original format: the name, Ro and mortality rate on the first three lines
+func parseLegacyPathogen(lines []string) (Pathogen, error) {
+  //Missing lines read as empty, which then fail to parse
+  for len(lines) < 3 {
+    lines = append(lines, "")
+  }
+
+  ro, err := parseRo(lines[1])
+  if err != nil {
+    return Pathogen{}, &PathogenError{2, "Ro", err}
+  }
+
+  deathRate, err := parseProbability(lines[2])
+  if err != nil {
+    return Pathogen{}, &PathogenError{3, "lethality", err}
+  }
+
+  return NewPathogen(lines[0], ro, deathRate), nil
+}
+
+//parseVersionedPathogen reads the "key = value" lines of a version 2 file, starting at index start
+func parseVersionedPathogen(lines []string, start int) (Pathogen, error) {
+  p := NewPathogen("", 0, 0)
+  seen := make(map[string]bool)
+
+  for i := start; i < len(lines); i++ {
+    line := lines[i]
+    lineNum := i + 1
+    if line == "" || strings.HasPrefix(line, "#") {
+      continue
+    }
+
+    parts := strings.SplitN(line, "=", 2)
+    if len(parts) != 2 {
+      return Pathogen{}, &PathogenError{lineNum, line, fmt.Errorf("expected key = value")}
+    }
+    key := strings.TrimSpace(parts[0])
+    value := strings.TrimSpace(parts[1])
+
+    if seen[key] {
+      return Pathogen{}, &PathogenError{lineNum, key, fmt.Errorf("set more than once")}
+    }
+    seen[key] = true
+
+    var err error
+    switch {
+    case key == "name":
+      if value == "" || strings.ContainsAny(value, " \t") {
+        err = fmt.Errorf("must be a single word")
+      }
+      p.Name = value
+    case key == "Ro":
+      p.Ro, err = parseRo(value)
+    case key == "lethality":
+      p.Lethality, err = parseProbability(value)
+    case key == "latent":
+      p.Latent, err = ParsePeriod(value)
+    case key == "infectious":
+      p.Infectious, err = ParsePeriod(value)
+    case key == "waning":
+      p.Waning, err = parseProbability(value)
+    case key == "vaccine.efficacy":
+      p.Vaccine.Efficacy, err = parseProbability(value)
+    case key == "vaccine.mode":
+      p.Vaccine.Mode, err = ParseVaccineMode(value)
+    case key == "vaccine.lethality":
+      p.Vaccine.Lethality, err = parseMultiplier(value)
+    case key == "vaccine.waning":
+      p.Vaccine.Waning, err = parseProbability(value)
+    case strings.HasPrefix(key, "age."):
+      group := strings.TrimPrefix(key, "age.")
+      if IsAgeGroup(group) == false {
+        err = fmt.Errorf("unknown age group %q, expected one of %s", group, strings.Join(AgeGroups, ", "))
+        break
+      }
+      var multiplier float64
+      multiplier, err = parseMultiplier(value)
+      if p.AgeLethality == nil {
+        p.AgeLethality = make(map[string]float64)
+      }
+      p.AgeLethality[group] = multiplier
+    default:
+      err = fmt.Errorf("unknown field")
+    }
+
+    if err != nil {
+      return Pathogen{}, &PathogenError{lineNum, key, err}
+    }
+  }
+
+  //The original three fields have no sensible defaults
+  for _, key := range []string{"name", "Ro", "lethality"} {
+    if seen[key] == false {
+      return Pathogen{}, &PathogenError{len(lines), key, fmt.Errorf("missing")}
+    }
+  }
+
+  return p, nil
+}
+
+//parseNumber reads a finite decimal number. strconv.ParseFloat accepts "NaN" and "Inf", which every comparison
+//of the range checks below would let through.
+func parseNumber(s string) (float64, error) {
+  v, err := strconv.ParseFloat(s, 64)
+  if err != nil {
+    return 0, fmt.Errorf("unable to parse %q", s)
+  } else if math.IsNaN(v) || math.IsInf(v, 0) {
+    return 0, fmt.Errorf("must be a finite decimal number, not %g", v)
+  }
+  return v, nil
+}
+
+//parseRo reads a base reproductive ratio, which must be greater than 0
+func parseRo(s string) (float64, error) {
+  ro, err := parseNumber(s)
+  if err != nil {
+    return 0, err
+  } else if ro <= 0.0 {
+    return 0, fmt.Errorf("must be a decimal number greater than 0, not %g", ro)
+  }
+  return ro, nil
+}
+
+//parseMultiplier reads a multiplier, a decimal number of at least 0
+func parseMultiplier(s string) (float64, error) {
+  v, err := parseNumber(s)
+  if err != nil {
+    return 0, err
+  } else if v < 0.0 {
+    return 0, fmt.Errorf("multiplier %g must not be negative", v)
+  }
+  return v, nil
+}
+
+//parseProbability reads a decimal number between 0 and 1, inclusive
+func parseProbability(s string) (float64, error) {
+  v, err := parseNumber(s)
+  if err != nil {
+    return 0, err
+  } else if v < 0.0 || v > 1.0 {
+    return 0, fmt.Errorf("must be a decimal number between 0 and 1, inclusive, not %g", v)
+  }
+  return v, nil
+}
@@ -0,0 +1,134 @@
+package epidemic
+
+import (
+  "errors"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+//TestParseVersionedPathogen reads a version 2 file that sets every field
+func TestParseVersionedPathogen(t *testing.T) {
+  file := `
+PATHOGEN 2
+# Every field, in the order of the documentation
+name = measles
+Ro = 15
+lethality = 0.03
+
+latent = fixed:10
+infectious = geometric:8
+waning = 0.001
+age.elderly = 3
+vaccine.efficacy = 0.97
+vaccine.mode = leaky
+vaccine.lethality = 0.5
+vaccine.waning = 0.002
+`
+  want := Pathogen{
+    Name: "measles",
+    Ro: 15,
+    Lethality: 0.03,
+    Latent: Period{"fixed", 10},
+    Infectious: Period{"geometric", 8},
+    Waning: 0.001,
+    AgeLethality: map[string]float64{"elderly": 3},
+    Vaccine: Vaccine{Efficacy: 0.97, Mode: Leaky, Lethality: 0.5, Waning: 0.002},
+  }
+
+  got, err := ParsePathogen(strings.NewReader(file))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if reflect.DeepEqual(got, want) == false {
+    t.Errorf("got %+v, want %+v", got, want)
+  }
+
+  //The optional fields keep the defaults of NewPathogen
+  got, err = ParsePathogen(strings.NewReader("PATHOGEN 2\nname = flu\nRo = 2.5\nlethality = 0.01\n"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if want := NewPathogen("flu", 2.5, 0.01); reflect.DeepEqual(got, want) == false {
+    t.Errorf("got %+v, want %+v", got, want)
+  }
+}
+
+//TestParseLegacyPathogen reads files without a header as the original three line format
+func TestParseLegacyPathogen(t *testing.T) {
+  got, err := ParsePathogen(strings.NewReader("flu\n2.5\n0.01\n"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if want := NewPathogen("flu", 2.5, 0.01); reflect.DeepEqual(got, want) == false {
+    t.Errorf("got %+v, want %+v", got, want)
+  }
+
+  //Windows line endings and surrounding spaces are trimmed
+  got, err = ParsePathogen(strings.NewReader("measles\r\n 15 \r\n0.03\r\n"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if want := NewPathogen("measles", 15, 0.03); reflect.DeepEqual(got, want) == false {
+    t.Errorf("got %+v, want %+v", got, want)
+  }
+}
+
+//TestParsePathogenErrors checks that every invalid file is rejected with the line and field at fault
+func TestParsePathogenErrors(t *testing.T) {
+  const header = "PATHOGEN 2\nname = flu\nRo = 2.5\nlethality = 0.01\n"
+  tests := []struct {
+    name string
+    file string
+    line int
+    field string
+  }{
+    {"legacy Ro", "flu\nfast\n0.01\n", 2, "Ro"},
+    {"legacy zero Ro", "flu\n0\n0.01\n", 2, "Ro"},
+    {"legacy NaN Ro", "flu\nNaN\n0.01\n", 2, "Ro"},
+    {"legacy lethality", "flu\n2.5\n1.5\n", 3, "lethality"},
+    {"legacy infinite lethality", "flu\n2.5\nInf\n", 3, "lethality"},
+    {"legacy missing lines", "flu\n2.5\n", 3, "lethality"},
+    {"unsupported version", "PATHOGEN 3\nname = flu\n", 1, "version"},
+    {"no equals sign", header + "latent fixed:2\n", 5, "latent fixed:2"},
+    {"repeated field", header + "Ro = 3\n", 5, "Ro"},
+    {"unknown field", header + "colour = green\n", 5, "colour"},
+    {"name with spaces", "PATHOGEN 2\nname = swine flu\n", 2, "name"},
+    {"empty name", "PATHOGEN 2\nname =\n", 2, "name"},
+    {"unparsable Ro", "PATHOGEN 2\nRo = high\n", 2, "Ro"},
+    {"negative Ro", "PATHOGEN 2\nRo = -1\n", 2, "Ro"},
+    {"NaN Ro", "PATHOGEN 2\nRo = NaN\n", 2, "Ro"},
+    {"infinite Ro", "PATHOGEN 2\nRo = +Inf\n", 2, "Ro"},
+    {"lethality above 1", "PATHOGEN 2\nlethality = 2\n", 2, "lethality"},
+    {"NaN lethality", "PATHOGEN 2\nlethality = nan\n", 2, "lethality"},
+    {"latent distribution", header + "latent = weibull:3\n", 5, "latent"},
+    {"negative latent", header + "latent = fixed:-1\n", 5, "latent"},
+    {"infectious geometric below 1", header + "infectious = geometric:0.5\n", 5, "infectious"},
+    {"infinite infectious", header + "infectious = geometric:Inf\n", 5, "infectious"},
+    {"waning above 1", header + "waning = 1.1\n", 5, "waning"},
+    {"NaN waning", header + "waning = NaN\n", 5, "waning"},
+    {"unknown age group", header + "age.teen = 2\n", 5, "age.teen"},
+    {"negative age multiplier", header + "age.child = -2\n", 5, "age.child"},
+    {"infinite age multiplier", header + "age.child = Inf\n", 5, "age.child"},
+    {"vaccine efficacy", header + "vaccine.efficacy = 97\n", 5, "vaccine.efficacy"},
+    {"vaccine mode", header + "vaccine.mode = partial\n", 5, "vaccine.mode"},
+    {"vaccine lethality", header + "vaccine.lethality = -0.5\n", 5, "vaccine.lethality"},
+    {"vaccine waning", header + "vaccine.waning = NaN\n", 5, "vaccine.waning"},
+    {"missing name", "PATHOGEN 2\nRo = 2.5\nlethality = 0.01\n", 3, "name"},
+    {"missing Ro", "PATHOGEN 2\nname = flu\nlethality = 0.01\n", 3, "Ro"},
+    {"missing lethality", "PATHOGEN 2\nname = flu\nRo = 2.5\n", 3, "lethality"},
+  }
+
+  for _, test := range tests {
+    _, err := ParsePathogen(strings.NewReader(test.file))
+    var perr *PathogenError
+    if errors.As(err, &perr) == false {
+      t.Errorf("%s: got error %v, want a *PathogenError", test.name, err)
+      continue
+    }
+    if perr.Line != test.line || perr.Field != test.field {
+      t.Errorf("%s: got line %d field %q, want line %d field %q (%v)", test.name, perr.Line, perr.Field, test.line,
+        test.field, err)
+    }
+  }
+}
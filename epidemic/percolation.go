@@ -0,0 +1,168 @@
+package epidemic
+
+import (
+  "encoding/json"
+  "math"
+)
+
+//The spread of an epidemic in which every contact transmits the disease independently with probability T is a
+//bond percolation problem on the contact network (Newman 2002, Meyers et al.). With p_k the fraction of nodes of
+//degree k, the generating functions of the degree distribution and of the excess degree of a neighbor are
+//
+//  G0(x) = sum p_k x^k      G1(x) = G0'(x) / G0'(1) = sum k p_k x^(k-1) / <k>
+//
+//from which follow the epidemic threshold, the size of a major outbreak and its probability, see PredictOutbreak.
+//Vaccinating a random fraction of the population removes those nodes before the bonds are percolated.
+
+//EffectiveCoverage returns the fraction of the population the vaccine actually protects when the fraction coverage
+//is vaccinated. A leaky vaccine is treated like an all-or-nothing vaccine of the same efficacy, which is exact for
+//the first generations of an outbreak but underestimates how far it spreads among the vaccinated later on.
+func (v Vaccine) EffectiveCoverage(coverage float64) float64 {
+  if v.Perfect() {
+    return coverage
+  }
+  return coverage * v.Efficacy
+}
+
+//DegreeDistribution returns the fraction of nodes of the network with each degree, p_k at index k
+func (n Network) DegreeDistribution() []float64 {
+  p := make([]float64, 0)
+  for i := range n {
+    k := len(n[i].Connections)
+    for len(p) <= k {
+      p = append(p, 0)
+    }
+    p[k]++
+  }
+  for k := range p {
+    p[k] /= float64(len(n))
+  }
+  return p
+}
+
+//G0 evaluates the generating function of the degree distribution p at x
+func G0(p []float64, x float64) float64 {
+  sum := 0.0
+  xk := 1.0
+  for k := range p {
+    sum += p[k] * xk
+    xk *= x
+  }
+  return sum
+}
+
+//G1 evaluates the generating function of the excess degree (the number of other neighbors of a node reached by
+//following an edge) of the degree distribution p at x
+func G1(p []float64, x float64) float64 {
+  sum, mean := 0.0, 0.0
+  xk := 1.0
+  for k := 1; k < len(p); k++ {
+    sum += float64(k) * p[k] * xk
+    mean += float64(k) * p[k]
+    xk *= x
+  }
+  if mean == 0 {
+    return 1
+  }
+  return sum / mean
+}
+
+//Prediction holds the percolation theory predictions for an epidemic in a network
+type Prediction struct {
+  //Transmissibility is the probability T that a contact transmits the disease, capped at 1
+  Transmissibility float64 `json:"transmissibility"`
+  //Coverage is the fraction of the population vaccinated
+  Coverage float64 `json:"coverage"`
+  //Threshold is the critical transmissibility above which major outbreaks are possible, taking vaccination into
+  //account. For random vaccination it is T_c / (1 - Coverage) with T_c = <k> / (<k^2> - <k>), and for targeted
+  //vaccination T_c of the network of the unvaccinated. It is infinite (null in JSON) if no outbreak is possible.
+  Threshold float64 `json:"threshold"`
+  Epidemic bool `json:"epidemic"`
+  //OutbreakSize is the expected fraction of the whole population infected in a major outbreak
+  OutbreakSize float64 `json:"outbreak_size"`
+  //MajorProbability is the probability that the patients zero start a major outbreak
+  MajorProbability float64 `json:"major_probability"`
+}
+
+//PredictOutbreak returns the percolation theory predictions for a disease of transmissibility T spreading from
+//pZero random susceptible people in network n, of which the fraction coverage is vaccinated at random. With
+//phi = 1 - coverage, the probability u that an edge does not lead to the giant outbreak is the smallest solution of
+//
+//  u = 1 - phi T + phi T G1(u)
+//
+//and a major outbreak reaches the fraction S = phi (1 - G0(u)) of the population. A single unvaccinated patient
+//zero starts one with probability 1 - G0(u), so pZero of them with probability 1 - G0(u)^pZero. The predictions
+//assume undirected contacts, random vaccination and every contact transmitting with the same probability T, so
+//they are a baseline for the simulation rather than an exact forecast of it.
+func PredictOutbreak(n Network, T, coverage float64, pZero int) Prediction {
+  T = math.Max(0, math.Min(T, 1))
+  pred := Prediction{Transmissibility: T, Coverage: coverage}
+
+  k := n.MeanDegree()
+  k2 := n.MeanSquaredDegree()
+  pred.Threshold = k / (k2 - k) / (1 - coverage)
+  if k2 - k <= 0 || coverage >= 1 {
+    pred.Threshold = math.Inf(1)
+  }
+
+  phiT := (1 - coverage) * T
+  pred.Epidemic = T > pred.Threshold
+
+  //Iterate from u = 0, which converges to the smallest solution (u = 1 below the threshold)
+  p := n.DegreeDistribution()
+  u := 0.0
+  for i := 0; i < 100000; i++ {
+    next := 1 - phiT + phiT * G1(p, u)
+    if math.Abs(next - u) < 1e-12 {
+      u = next
+      break
+    }
+    u = next
+  }
+
+  single := 1 - G0(p, u)
+  pred.OutbreakSize = (1 - coverage) * single
+  pred.MajorProbability = 1 - math.Pow(1 - single, float64(pZero))
+  return pred
+}
+
+//PredictTargeted returns the percolation theory predictions for a disease of transmissibility T spreading from
+//pZero random susceptible people in network n, which has already been vaccinated in any way. Targeted
+//vaccination does not remove nodes at random, so rather than averaging over the vaccination the predictions are
+//made for the network of the unvaccinated (see Network.Unvaccinated).
+func PredictTargeted(n Network, T float64, pZero int) Prediction {
+  residual := n.Unvaccinated()
+  if len(residual) == 0 {
+    return Prediction{Transmissibility: math.Max(0, math.Min(T, 1)), Coverage: 1, Threshold: math.Inf(1)}
+  }
+
+  phi := float64(len(residual)) / float64(len(n))
+  pred := PredictOutbreak(residual, T, 0, pZero)
+  pred.Coverage = 1 - phi
+  pred.OutbreakSize *= phi
+  return pred
+}
+
+//ScenarioPrediction returns the percolation theory predictions for scenario s in network n, which has been
+//vaccinated according to the scenario but not yet stepped through the epidemic, whose infections and waning
+//immunity change who is vaccinated. It uses the transmissibility of the pathogen in that network (see
+//Transmissibility), scaled by the traits of its nodes (see Network.EffectiveTransmissibility). Random vaccination
+//uses PredictOutbreak with the coverage the vaccine actually protects (see Vaccine.EffectiveCoverage) and the other
+//strategies PredictTargeted. All-or-nothing vaccine failures are already susceptible again in n, but a leaky
+//vaccine leaves everyone it was given to out of the targeted predictions.
+func ScenarioPrediction(s Scenario, n Network) Prediction {
+  T := n.EffectiveTransmissibility(Transmissibility(s.Pathogen.Ro, n))
+  if _, random := s.Strategy.(RandomStrategy); s.Strategy == nil || random {
+    return PredictOutbreak(n, T, s.Pathogen.Vaccine.EffectiveCoverage(s.VaccineRate / 100.0), s.PatientsZero)
+  }
+  return PredictTargeted(n, T, s.PatientsZero)
+}
+
+//MarshalJSON writes an infinite threshold as null
+func (p Prediction) MarshalJSON() ([]byte, error) {
+  type plain Prediction
+  return json.Marshal(struct {
+    plain
+    Threshold *float64 `json:"threshold"`
+  }{plain(p), jsonFloat(p.Threshold)})
+}
@@ -0,0 +1,110 @@
+package epidemic
+
+import (
+  "encoding/json"
+  "fmt"
+  "math"
+  "math/rand"
+  "strconv"
+  "strings"
+)
+
+//Period describes how many timesteps a node spends in one stage of a disease (the latent or the infectious period).
+//Dist is the distribution of its length and Mean the mean length. A "fixed" period always lasts the mean, rounded
+//to the nearest timestep. "geometric" and "poisson" periods draw a new length for every infection, with the given
+//mean.
+type Period struct {
+  Dist string
+  Mean float64
+}
+
+//maxPeriodMean is the longest mean a period may have, in timesteps. Longer ones would overflow the timers or take
+//as many draws as timesteps, and no epidemic runs that long anyway.
+const maxPeriodMean = 100000.0
+
+//ParsePeriod reads a period written as "dist:mean" (for example "geometric:4.5"). A plain number is a fixed period.
+func ParsePeriod(s string) (Period, error) {
+  dist := "fixed"
+  meanString := s
+  if strings.Contains(s, ":") {
+    parts := strings.SplitN(s, ":", 2)
+    dist = parts[0]
+    meanString = parts[1]
+  }
+
+  mean, err := strconv.ParseFloat(meanString, 64)
+  if err != nil {
+    return Period{}, fmt.Errorf("unable to parse period length %q", meanString)
+  } else if math.IsNaN(mean) || math.IsInf(mean, 0) {
+    return Period{}, fmt.Errorf("period length must be a finite number, not %g", mean)
+  } else if mean < 0.0 {
+    return Period{}, fmt.Errorf("period length %g must not be negative", mean)
+  } else if mean > maxPeriodMean {
+    return Period{}, fmt.Errorf("period length %g must not be more than %g timesteps", mean, maxPeriodMean)
+  }
+
+  if dist == "geometric" && mean < 1.0 {
+    return Period{}, fmt.Errorf("a geometric period must have a mean of at least 1, not %g", mean)
+  } else if dist != "fixed" && dist != "geometric" && dist != "poisson" {
+    return Period{}, fmt.Errorf("unknown period distribution %q, expected fixed, geometric or poisson", dist)
+  }
+
+  return Period{dist, mean}, nil
+}
+
+//String writes the period in the same "dist:mean" form ParsePeriod reads
+func (p Period) String() string {
+  return p.Dist + ":" + strconv.FormatFloat(p.Mean, 'g', -1, 64)
+}
+
+//MarshalJSON writes the period as a JSON string in the same "dist:mean" form
+func (p Period) MarshalJSON() ([]byte, error) {
+  return json.Marshal(p.String())
+}
+
+//poissonNormalMean is the mean above which poisson periods are drawn from the normal approximation
+const poissonNormalMean = 500.0
+
+//Draw returns the number of timesteps one node spends in this period
+func (p Period) Draw(r *rand.Rand) int {
+  if p.Dist == "geometric" {
+    //Leave the stage with probability 1/mean every timestep, counting the timestep we leave on
+    steps := 1
+    for r.Float64() >= 1.0 / p.Mean {
+      steps++
+    }
+    return steps
+  } else if p.Dist == "poisson" {
+    //e^-mean underflows to 0 for long periods, which Knuth's method below would never reach, and the method takes
+    //mean draws anyway. A Poisson distribution with a large mean is nearly normal.
+    if p.Mean > poissonNormalMean {
+      steps := math.Round(p.Mean + r.NormFloat64() * math.Sqrt(p.Mean))
+      return int(math.Max(steps, 0))
+    }
+
+    //Knuth's method, multiplying uniform draws until they fall below e^-mean
+    limit := math.Exp(-p.Mean)
+    steps := 0
+    prod := r.Float64()
+    for prod > limit {
+      steps++
+      prod *= r.Float64()
+    }
+    return steps
+  }
+
+  return int(math.Round(p.Mean))
+}
+
+//Duration returns how long one node spends in this period in continuous time, for the EventEngine. A fixed period
+//lasts exactly its mean and a poisson period a whole number of timesteps as in Draw, while a geometric period is
+//exponentially distributed, its counterpart in continuous time.
+func (p Period) Duration(r *rand.Rand) float64 {
+  if p.Dist == "geometric" {
+    return r.ExpFloat64() * p.Mean
+  } else if p.Dist == "poisson" {
+    return float64(p.Draw(r))
+  }
+
+  return p.Mean
+}
@@ -0,0 +1,29 @@
+package epidemic
+
+import (
+  "testing"
+)
+
+//TestParsePeriod checks the periods ParsePeriod accepts and that it rejects those Draw could not handle
+func TestParsePeriod(t *testing.T) {
+  valid := map[string]Period{
+    "3": {"fixed", 3},
+    "fixed:0": {"fixed", 0},
+    "geometric:4.5": {"geometric", 4.5},
+    "poisson:100000": {"poisson", 100000},
+  }
+  for s, want := range valid {
+    got, err := ParsePeriod(s)
+    if err != nil || got != want {
+      t.Errorf("%q: got %v, %v, want %v", s, got, err, want)
+    }
+  }
+
+  invalid := []string{"", "fixed:", "long", "fixed:-1", "geometric:0.5", "weibull:2", "NaN", "geometric:NaN",
+    "geometric:Inf", "fixed:+Inf", "poisson:-Inf", "fixed:1e300", "geometric:100001"}
+  for _, s := range invalid {
+    if p, err := ParsePeriod(s); err == nil {
+      t.Errorf("%q: got %v, want an error", s, p)
+    }
+  }
+}
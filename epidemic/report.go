@@ -0,0 +1,150 @@
+package epidemic
+
+import (
+  "encoding/json"
+  "fmt"
+  "math"
+  "os"
+)
+
+//Report is the machine readable summary of one simulated epidemic: everything that went into it and the
+//statistics that came out of it. Values that cannot be computed (such as the frailty of a network without any
+//susceptible nodes left) are null in JSON.
+type Report struct {
+  Pathogen Pathogen `json:"pathogen"`
+  Population int `json:"population"`
+  //VaccineRate is the percentage of the population that was vaccinated
+  VaccineRate float64 `json:"vaccine_rate"`
+  VaccineStrategy string `json:"vaccine_strategy"`
+  PatientsZero int `json:"patients_zero"`
+  Seed int64 `json:"seed"`
+  Engine string `json:"engine"`
+  //MaxEpochs is the number of timesteps the simulation was stopped after, 0 for no limit
+  MaxEpochs int `json:"max_epochs"`
+  Network NetworkReport `json:"network"`
+
+  //Final is the state of the network once the epidemic was over
+  Final Epoch `json:"final"`
+  //AttackRate is the fraction of the population that was infected at least once. People read as recovered from a
+  //network file are only part of it if they were infected again, and are counted in PriorImmune otherwise.
+  AttackRate float64 `json:"attack_rate"`
+  PriorImmune int `json:"prior_immune"`
+  //CaseFatalityRatio is the fraction of all infections that ended in death
+  CaseFatalityRatio *float64 `json:"case_fatality_ratio"`
+  //Vaccinated is the number of people who got the vaccine, Breakthrough how many of them were infected anyway and
+  //BreakthroughDeaths how many of those died
+  Vaccinated int `json:"vaccinated"`
+  Breakthrough int `json:"breakthrough"`
+  BreakthroughDeaths int `json:"breakthrough_deaths"`
+  Duration int `json:"duration"`
+  //PeakPrevalence is the largest number of people exposed or infected at the same time, first reached at PeakEpoch
+  PeakPrevalence int `json:"peak_prevalence"`
+  PeakPrevalenceFraction float64 `json:"peak_prevalence_fraction"`
+  PeakEpoch int `json:"peak_epoch"`
+  Frailty *float64 `json:"frailty"`
+  Interference *float64 `json:"interference"`
+  Transmission TransmissionStats `json:"transmission"`
+  Reproduction ReproductionStats `json:"reproduction"`
+  //Prediction is what percolation theory expects of the epidemic, to compare with the simulated outcome
+  Prediction Prediction `json:"prediction"`
+  //Endemic describes the recurring waves and endemic equilibrium of pathogens with waning immunity
+  Endemic EndemicStats `json:"endemic"`
+}
+
+//NetworkReport describes the contact network an epidemic spread through
+type NetworkReport struct {
+  Model string `json:"model"`
+  Parameters map[string]float64 `json:"parameters"`
+  MeanDegree float64 `json:"mean_degree"`
+  MeanSquaredDegree float64 `json:"mean_squared_degree"`
+  //Transmissibility is the probability that an infected node infects a neighbor, see Transmissibility, before it
+  //is scaled by the traits of the two nodes. EffectiveTransmissibility is its mean over the contacts of the network,
+  //see Network.EffectiveTransmissibility.
+  Transmissibility float64 `json:"transmissibility"`
+  EffectiveTransmissibility float64 `json:"effective_transmissibility"`
+  Traits TraitStats `json:"traits"`
+}
+
+//NewReport summarizes the epidemic of scenario s, which left behind the network n and the epidemic curve ts. pred
+//is the prediction for the network before the epidemic, see ScenarioPrediction.
+func NewReport(s Scenario, n Network, ts TimeSeries, pred Prediction) Report {
+  susceptibility, infectiousness := s.Traits()
+  rep := Report{
+    Pathogen: s.Pathogen,
+    Population: len(n),
+    VaccineRate: s.VaccineRate,
+    VaccineStrategy: StrategyName(s.Strategy),
+    PatientsZero: s.PatientsZero,
+    Seed: s.Seed,
+    Engine: EngineName(s.Engine),
+    MaxEpochs: s.MaxEpochs,
+    Network: NetworkReport{
+      Model: s.Generator().Name(),
+      Parameters: s.Generator().Parameters(),
+      MeanDegree: n.MeanDegree(),
+      MeanSquaredDegree: n.MeanSquaredDegree(),
+      Transmissibility: Transmissibility(s.Pathogen.Ro, n),
+      EffectiveTransmissibility: n.EffectiveTransmissibility(Transmissibility(s.Pathogen.Ro, n)),
+      Traits: NewTraitStats(n, susceptibility, infectiousness),
+    },
+    Final: ts[len(ts) - 1],
+    Duration: ts.Duration(),
+    Frailty: jsonFloat(NetworkFrailty(n)),
+    Interference: jsonFloat(NetworkInterference(n)),
+    Prediction: pred,
+    Endemic: NewEndemicStats(ts),
+  }
+
+  tree := BuildTransmissionTree(n)
+  rep.Transmission = NewTransmissionStats(tree, n)
+  rep.Reproduction = NewReproductionStats(s.Pathogen, tree)
+
+  everInfected := 0
+  for i := range n {
+    if n[i].Infections > 0 {
+      everInfected++
+    }
+  }
+  rep.AttackRate = float64(everInfected) / float64(len(n))
+  rep.CaseFatalityRatio = jsonFloat(float64(rep.Final.Dead) / float64(rep.Final.TotalInfections))
+
+  counts := CountStatuses(n)
+  rep.PriorImmune = counts["prior immune"]
+  rep.Vaccinated = counts["vaccinated"]
+  rep.Breakthrough = counts["breakthrough"]
+  rep.BreakthroughDeaths = counts["breakthrough dead"]
+
+  for _, e := range ts {
+    if e.Exposed + e.Infected > rep.PeakPrevalence {
+      rep.PeakPrevalence = e.Exposed + e.Infected
+      rep.PeakEpoch = e.Epoch
+    }
+  }
+  rep.PeakPrevalenceFraction = float64(rep.PeakPrevalence) / float64(len(n))
+
+  return rep
+}
+
+//jsonFloat returns a pointer to x, or nil if x has no JSON representation (NaN or infinite)
+func jsonFloat(x float64) *float64 {
+  if math.IsNaN(x) || math.IsInf(x, 0) {
+    return nil
+  }
+  return &x
+}
+
+//WriteReportToFile writes the report as JSON to [outName]_summary.json
+func WriteReportToFile(rep Report, outName string) error {
+  file, err := os.Create(outName + "_summary.json")
+  if err != nil {
+    return fmt.Errorf("cannot create summary file: %w", err)
+  }
+  defer file.Close()
+
+  enc := json.NewEncoder(file)
+  enc.SetIndent("", "  ")
+  if err := enc.Encode(rep); err != nil {
+    return fmt.Errorf("writing %s_summary.json: %w", outName, err)
+  }
+  return file.Close()
+}
@@ -0,0 +1,123 @@
+package epidemic
+
+import (
+  "math"
+)
+
+//EarlyGenerations is the number of generations, starting with the patients zero, whose mean number of secondary
+//cases is the realized R0 of an outbreak. Later generations meet a population already depleted by the epidemic.
+const EarlyGenerations = 3
+
+//RealizedR0 returns the mean number of secondary cases caused by the infections of the first generations of the
+//tree (the patients zero being generation 0), NaN if there were none
+func (t TransmissionTree) RealizedR0(generations int) float64 {
+  total, count := 0, 0
+  for i, e := range t.Events {
+    if e.Generation < generations {
+      total += t.Offspring[i]
+      count++
+    }
+  }
+  return float64(total) / float64(count)
+}
+
+//ReproductionByEpoch returns the effective reproduction number R_t for timesteps 0 to epochs - 1: the mean number
+//of secondary cases caused by the infections that happened in timestep t, NaN for timesteps without infections
+func (t TransmissionTree) ReproductionByEpoch(epochs int) []float64 {
+  total := make([]int, epochs)
+  count := make([]int, epochs)
+  for i, e := range t.Events {
+    if e.Epoch < epochs {
+      total[e.Epoch] += t.Offspring[i]
+      count[e.Epoch]++
+    }
+  }
+
+  rt := make([]float64, epochs)
+  for i := range rt {
+    rt[i] = float64(total[i]) / float64(count[i])
+  }
+  return rt
+}
+
+//Dispersion returns the maximum likelihood estimate of the dispersion parameter k of a negative binomial
+//distribution fitted to the offspring distribution of the tree. A small k means a few infections cause most of the
+//others (k = 0.16 for SARS), and k grows without bound as the offspring distribution approaches a Poisson
+//distribution, in which case +Inf is returned. NaN is returned if there are no infections or no secondary cases.
+func (t TransmissionTree) Dispersion() float64 {
+  n := float64(len(t.Offspring))
+  mean := t.MeanOffspring()
+  if len(t.Offspring) == 0 || mean == 0 {
+    return math.NaN()
+  }
+
+  variance := 0.0
+  for _, x := range t.Offspring {
+    variance += (float64(x) - mean) * (float64(x) - mean)
+  }
+  variance /= n - 1
+  if variance <= mean {
+    return math.Inf(1)
+  }
+
+  //The derivative of the log likelihood in k, with the mean fixed at its estimate, is
+  //sum_i [digamma(x_i + k) - digamma(k)] + n log(k / (k + mean)), where for whole numbers x_i the difference of
+  //digammas is the sum of 1 / (k + j) for j from 0 to x_i - 1. It falls from positive to negative as k grows,
+  //so bisect on log k for its root.
+  dist := t.OffspringDistribution()
+  score := func(k float64) float64 {
+    s := n * math.Log(k / (k + mean))
+    partial := 0.0
+    for x := 1; x < len(dist); x++ {
+      partial += 1.0 / (k + float64(x - 1))
+      s += float64(dist[x]) * partial
+    }
+    return s
+  }
+
+  lo, hi := math.Log(1e-6), math.Log(1e6)
+  if score(math.Exp(hi)) > 0 {
+    return math.Inf(1)
+  }
+  for i := 0; i < 100; i++ {
+    mid := (lo + hi) / 2
+    if score(math.Exp(mid)) > 0 {
+      lo = mid
+    } else {
+      hi = mid
+    }
+  }
+  return math.Exp((lo + hi) / 2)
+}
+
+//SetReproductionNumbers fills in the effective reproduction number R_t of every timestep of the time series from
+//the transmission tree of the epidemic
+func (ts TimeSeries) SetReproductionNumbers(t TransmissionTree) {
+  rt := t.ReproductionByEpoch(len(ts))
+  for i := range ts {
+    ts[i].Rt = jsonFloat(rt[i])
+  }
+}
+
+//ReproductionStats compares the Ro given to a pathogen with the reproduction numbers its outbreak actually reached
+type ReproductionStats struct {
+  InputRo float64 `json:"input_ro"`
+  //RealizedR0 is the mean number of secondary cases in the first EarlyGenerations generations
+  RealizedR0 *float64 `json:"realized_r0"`
+  EarlyGenerations int `json:"early_generations"`
+  //MeanOffspring is the mean number of secondary cases over the whole outbreak
+  MeanOffspring *float64 `json:"mean_offspring"`
+  //Dispersion is the dispersion parameter k of the offspring distribution, null when it is infinite (Poisson)
+  Dispersion *float64 `json:"dispersion_k"`
+}
+
+//NewReproductionStats compares the input Ro of pathogen p with the outbreak recorded in the transmission tree
+func NewReproductionStats(p Pathogen, t TransmissionTree) ReproductionStats {
+  return ReproductionStats{
+    InputRo: p.Ro,
+    RealizedR0: jsonFloat(t.RealizedR0(EarlyGenerations)),
+    EarlyGenerations: EarlyGenerations,
+    MeanOffspring: jsonFloat(t.MeanOffspring()),
+    Dispersion: jsonFloat(t.Dispersion()),
+  }
+}
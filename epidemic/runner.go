@@ -0,0 +1,151 @@
+package epidemic
+
+import (
+  "context"
+  "math/rand"
+  "runtime"
+  "sync"
+)
+
+//Runner runs the replicates of ensembles and sweeps on a pool of Workers goroutines, all the CPUs for 0. Every
+//replicate draws from its own generator, seeded from the master seed of the scenario before any of them starts,
+//so the results are the same whatever the number of workers. If Progress is not nil it is called after every
+//replicate with the number finished so far and the total, one call at a time.
+//
+//Replicates of the same scenario run at the same time, so its Network, Strategy and Engine must be safe to use
+//from several goroutines at once. The ones in this package are, as long as an EventEngine does not Record events.
+type Runner struct {
+  Workers int
+  Progress func(done, total int)
+}
+
+//replicateJob is one replicate to run: the replicate index of the ensemble numbered point
+type replicateJob struct {
+  point int
+  index int
+  s Scenario
+  seed int64
+}
+
+//workers returns the number of goroutines the runner runs replicates on
+func (run Runner) workers() int {
+  if run.Workers > 0 {
+    return run.Workers
+  }
+  return runtime.NumCPU()
+}
+
+//replicateSeeds draws the seeds of runs replicates from a master generator seeded with seed, in order
+func replicateSeeds(seed int64, runs int) []int64 {
+  master := rand.New(rand.NewSource(seed))
+  seeds := make([]int64, runs)
+  for i := range seeds {
+    seeds[i] = master.Int63()
+  }
+  return seeds
+}
+
+//run runs every job on the pool of workers and returns the results of every ensemble (indexed by point, then
+//replicate) along with which of them finished. Once ctx is cancelled no new replicate starts, the ones running
+//finish, and ctx.Err() is returned unless they were the last ones.
+func (run Runner) run(ctx context.Context, jobs []replicateJob, points, runs int) ([][]ReplicateResult, [][]bool, error) {
+  results := make([][]ReplicateResult, points)
+  finished := make([][]bool, points)
+  for i := range results {
+    results[i] = make([]ReplicateResult, runs)
+    finished[i] = make([]bool, runs)
+  }
+
+  queue := make(chan replicateJob)
+  var mu sync.Mutex
+  var wg sync.WaitGroup
+  done := 0
+  for w := 0; w < run.workers(); w++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      for job := range queue {
+        res := RunReplicate(job.s, job.seed)
+
+        //Every job writes its own result, but the progress count is shared
+        mu.Lock()
+        results[job.point][job.index] = res
+        finished[job.point][job.index] = true
+        done++
+        if run.Progress != nil {
+          run.Progress(done, len(jobs))
+        }
+        mu.Unlock()
+      }
+    }()
+  }
+
+  //Hand out the jobs in order until they run out or the context is cancelled
+  for _, job := range jobs {
+    if ctx.Err() != nil {
+      break
+    }
+    select {
+    case queue <- job:
+    case <-ctx.Done():
+    }
+  }
+  close(queue)
+  wg.Wait()
+
+  if done == len(jobs) {
+    return results, finished, nil
+  }
+  return results, finished, ctx.Err()
+}
+
+//Ensemble runs runs replicates of a scenario in parallel, like RunEnsemble. If ctx is cancelled before they are
+//all done it returns the replicates that finished, in order, along with ctx.Err().
+func (run Runner) Ensemble(ctx context.Context, s Scenario, runs int) ([]ReplicateResult, error) {
+  jobs := make([]replicateJob, runs)
+  for i, seed := range replicateSeeds(s.Seed, runs) {
+    jobs[i] = replicateJob{0, i, s, seed}
+  }
+
+  results, finished, err := run.run(ctx, jobs, 1, runs)
+  if err == nil {
+    return results[0], nil
+  }
+  completed := make([]ReplicateResult, 0, runs)
+  for i := range results[0] {
+    if finished[0][i] {
+      completed = append(completed, results[0][i])
+    }
+  }
+  return completed, err
+}
+
+//Sweep runs the ensembles of a parameter sweep in parallel, like RunSweep, with the replicates of all the points
+//sharing the pool of workers. If ctx is cancelled before they are all done it returns the points whose replicates
+//all finished, in order, along with ctx.Err().
+func (run Runner) Sweep(ctx context.Context, s Scenario, runs int, coverages, ros []float64, strategies []Strategy) ([]SweepPoint, error) {
+  points := sweepPoints(s, coverages, ros, strategies)
+
+  //Every point reuses the same seeds, see RunSweep
+  seeds := replicateSeeds(s.Seed, runs)
+  jobs := make([]replicateJob, 0, len(points) * runs)
+  for p := range points {
+    for i, seed := range seeds {
+      jobs = append(jobs, replicateJob{p, i, points[p].scenario, seed})
+    }
+  }
+
+  results, finished, err := run.run(ctx, jobs, len(points), runs)
+  completed := make([]SweepPoint, 0, len(points))
+  for p := range points {
+    whole := true
+    for i := range finished[p] {
+      whole = whole && finished[p][i]
+    }
+    if whole {
+      points[p].Results = results[p]
+      completed = append(completed, points[p].SweepPoint)
+    }
+  }
+  return completed, err
+}
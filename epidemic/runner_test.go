@@ -0,0 +1,103 @@
+package epidemic
+
+import (
+  "context"
+  "errors"
+  "reflect"
+  "testing"
+)
+
+//runnerTestWorkers lists the pool sizes the runner is checked with: one worker, a couple, and one per CPU
+var runnerTestWorkers = []int{1, 2, 0}
+
+//TestRunnerWorkers checks that the replicates of an ensemble and a sweep come out the same whatever the number of
+//workers they ran on, and the same as running them one after the other
+func TestRunnerWorkers(t *testing.T) {
+  s := compactTestScenario(t, "erdos-renyi:k=6", 500)
+  s.Seed = 42
+  const runs = 12
+  want := RunEnsemble(s, runs)
+  wantSweep := RunSweep(s, 4, []float64{0, 40}, []float64{1.5, 3}, []Strategy{RandomStrategy{}, DegreeStrategy{}})
+
+  for _, w := range runnerTestWorkers {
+    got, err := Runner{Workers: w}.Ensemble(context.Background(), s, runs)
+    if err != nil {
+      t.Fatalf("%d workers: %v", w, err)
+    }
+    if reflect.DeepEqual(got, want) == false {
+      t.Errorf("%d workers: ensemble differs from the replicates run in order", w)
+    }
+
+    gotSweep, err := Runner{Workers: w}.Sweep(context.Background(), s, 4, []float64{0, 40}, []float64{1.5, 3},
+      []Strategy{RandomStrategy{}, DegreeStrategy{}})
+    if err != nil {
+      t.Fatalf("%d workers: %v", w, err)
+    }
+    if reflect.DeepEqual(gotSweep, wantSweep) == false {
+      t.Errorf("%d workers: sweep differs from the points run in order", w)
+    }
+  }
+}
+
+//TestRunnerCancel checks that cancelling the context stops the pool from starting new replicates, and that only the
+//ones that finished are returned along with the error of the context
+func TestRunnerCancel(t *testing.T) {
+  s := compactTestScenario(t, "erdos-renyi:k=6", 500)
+  const runs = 50
+
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+  got, err := Runner{Workers: 2}.Ensemble(ctx, s, runs)
+  if errors.Is(err, context.Canceled) == false {
+    t.Errorf("cancelled before starting: got error %v, want %v", err, context.Canceled)
+  }
+  if len(got) != 0 {
+    t.Errorf("cancelled before starting: got %d replicates, want none", len(got))
+  }
+
+  want := RunEnsemble(s, runs)
+  for _, w := range runnerTestWorkers {
+    ctx, cancel := context.WithCancel(context.Background())
+    started := 0
+    run := Runner{Workers: w, Progress: func(done, total int) {
+      started = done
+      if done == 3 {
+        cancel()
+      }
+    }}
+    got, err := run.Ensemble(ctx, s, runs)
+    cancel()
+    if errors.Is(err, context.Canceled) == false {
+      t.Errorf("%d workers: got error %v, want %v", w, err, context.Canceled)
+    }
+    if len(got) >= runs || len(got) != started {
+      t.Errorf("%d workers: got %d of %d replicates after cancelling, with %d finished", w, len(got), runs, started)
+    }
+
+    //The replicates that finished are the first ones handed out, but not necessarily in a row
+    for _, res := range got {
+      found := false
+      for i := range want {
+        found = found || reflect.DeepEqual(res, want[i])
+      }
+      if found == false {
+        t.Errorf("%d workers: a replicate returned after cancelling is not one of the ensemble", w)
+      }
+    }
+  }
+
+  ctx, cancel = context.WithCancel(context.Background())
+  run := Runner{Workers: 1, Progress: func(done, total int) {
+    if done == 1 {
+      cancel()
+    }
+  }}
+  points, err := run.Sweep(ctx, s, 2, []float64{0, 20, 40}, []float64{2}, []Strategy{RandomStrategy{}})
+  cancel()
+  if errors.Is(err, context.Canceled) == false {
+    t.Errorf("sweep: got error %v, want %v", err, context.Canceled)
+  }
+  if len(points) > 1 {
+    t.Errorf("sweep: got %d points after cancelling in the first one, want at most 1", len(points))
+  }
+}
@@ -0,0 +1,499 @@
+//Package epidemic simulates the spread of a pathogen through a contact network, following the network model of
+//Meyers et al. by default. A population is built with BuildNetwork (or BuildNetworkWith one of the other
+//Generators), vaccinated with Network.Vaccinate, seeded with Network.SeedInfection and then stepped through time
+//with InfectOnce (or RunEpidemic, which steps an Engine until the epidemic is over). NetworkFrailty and
+//NetworkInterference measure the network the epidemic leaves behind, and RunEnsemble and RunSweep repeat whole
+//Scenarios many times.
+package epidemic
+
+import (
+  "fmt"
+  "math"
+  "math/rand"
+  "os"
+)
+
+//Scenario describes an epidemic to simulate: Pathogen let loose on PatientsZero people in a population of size
+//Population, of which VaccineRate percent (0 to 100) are vaccinated. Seed seeds the random number generator, or the
+//master generator of the replicates for ensembles and sweeps. Network builds the contact network, nil meaning the
+//Meyers network of BuildNetwork, and Strategy chooses who is vaccinated, nil meaning everyone independently with
+//probability VaccineRate (see Network.Vaccinate). Susceptibility and Infectiousness are the distributions of those
+//traits of the nodes, the zero Trait meaning DefaultSusceptibility and DefaultInfectiousness. Engine steps the
+//epidemic through time, nil meaning the SynchronousEngine, and MaxEpochs stops epidemics that are still going after
+//that many timesteps, 0 meaning they run until they are over (see RunEpidemic).
+type Scenario struct {
+  Pathogen Pathogen
+  Population int
+  VaccineRate float64
+  PatientsZero int
+  Seed int64
+  Network Generator
+  Strategy Strategy
+  Susceptibility Trait
+  Infectiousness Trait
+  Engine Engine
+  MaxEpochs int
+}
+
+//Traits returns the distributions of the susceptibility and infectiousness of the nodes of the scenario
+func (s Scenario) Traits() (Trait, Trait) {
+  susceptibility, infectiousness := s.Susceptibility, s.Infectiousness
+  if susceptibility.Dist == "" {
+    susceptibility = DefaultSusceptibility
+  }
+  if infectiousness.Dist == "" {
+    infectiousness = DefaultInfectiousness
+  }
+  return susceptibility, infectiousness
+}
+
+//BuildNetwork builds the contact network of the scenario and draws the traits of its nodes
+func (s Scenario) BuildNetwork(r *rand.Rand) Network {
+  net := BuildNetworkWith(r, s.Population, s.Generator())
+  susceptibility, infectiousness := s.Traits()
+  net.AssignTraits(r, susceptibility, infectiousness)
+  return net
+}
+
+//Vaccinate vaccinates the network of the scenario with its strategy, then decides who the vaccine of the pathogen
+//works for (see Vaccine.Protect). Strategies get a budget of doses for VaccineRate percent of the population.
+func (s Scenario) Vaccinate(r *rand.Rand, n Network) {
+  if s.Strategy == nil {
+    n.Vaccinate(r, s.VaccineRate / 100.0)
+  } else {
+    n.VaccinateWith(r, s.Strategy, Doses(s.VaccineRate / 100.0, len(n)))
+  }
+  s.Pathogen.Vaccine.Protect(r, n)
+}
+
+//Generator returns the generator of the scenario's contact network
+func (s Scenario) Generator() Generator {
+  if s.Network == nil {
+    return NewMeyersGenerator(s.Population)
+  }
+  return s.Network
+}
+
+//Returns the transmissibility as a function of the infectivity of a given pathogen (Ro) and a given network
+func Transmissibility(Ro float64, n Network) float64 {
+  k := n.MeanDegree()
+
+  k2 := n.MeanSquaredDegree()
+
+
+  T := (Ro / k2) * (k - 1.0)
+  return T
+
+}
+
+//Finally, some individuals are more susceptible to disease and mortality (elderly, immunocompromised people, children), and some are less. This is
+//modeled by adding a Gaussian term "vulnerability" that will function as a multiplier of the probability of mortality, and by default of
+//infection as well (see DefaultSusceptibility).
+//A vulnerability of 1 would represent the average of the population, and a SD of 0.556 (this is based on U.S. national percentages of elderly and immunocompromised individuals)
+func GaussianVuln(r *rand.Rand) float64 {
+  base := r.NormFloat64()
+  //Mean of 1, SD of 0.556
+  v := (base * 0.556) + 1
+
+  //It does not make sense to have vulnerability coefficient below 0, so we set a lower bound on v
+  if v <= 0.1 {
+    v = 0.1
+  }
+
+  return v
+}
+
+
+//Run one pass of infection through a given network. Recovered and vaccinated nodes may lose their immunity. Exposed nodes count down their latent period and become
+//infectious once it is over. Each infectious node infects its neighbors with a probability such that, over its whole
+//infectious period, it infects each of them with probability T equal to the transmissibility of the pathogen in that network,
+//times its own infectiousness and the susceptibility of the neighbor. Neighbors vaccinated with a leaky vaccine are infected with a
+//probability reduced by its efficacy. All random draws come from r. epoch is the number of the timestep, recorded as the infection time of new infections.
+//
+//Every node is updated from the status it had at the start of the timestep, so nodes infected during the timestep
+//neither infect anyone nor count down their latent or infectious period until the next one, whatever their ID.
+func InfectOnce(r *rand.Rand, n Network, p Pathogen, epoch int) Network {
+  snapshot := make([]string, len(n))
+  for i := range n {
+    snapshot[i] = n[i].Status
+  }
+  return infectStep(r, n, p, epoch, snapshot)
+}
+
+//InfectOnceInPlace is the original InfectOnce, which updates the status of every node as it goes. A node infected
+//by a node with a lower ID is then processed later in the same timestep as if it had been infected all along: it
+//infects its own neighbors, and with no latent period and an infectious period of one timestep it even recovers or
+//dies, so how far an infection travels in one timestep depends on the order of the IDs. It is kept to measure the
+//difference and to replay old runs.
+func InfectOnceInPlace(r *rand.Rand, n Network, p Pathogen, epoch int) Network {
+  return infectStep(r, n, p, epoch, nil)
+}
+
+//infectStep runs one timestep of infection. Nodes act on their status in snapshot, the statuses at the start of the
+//timestep indexed by ID, and only nodes still in that status can be infected. A nil snapshot updates in place.
+func infectStep(r *rand.Rand, n Network, p Pathogen, epoch int, snapshot []string) Network {
+  //First, compute the transmissibility of p in this network
+  transmitRate := Transmissibility(p.Ro, n)
+
+  //Spread T over the infectious period, so that 1 - (1 - rate)^period = T
+  if p.Infectious.Mean > 1.0 && transmitRate < 1.0 {
+    transmitRate = 1.0 - math.Pow(1.0 - transmitRate, 1.0 / p.Infectious.Mean)
+  }
+
+  //Now, range over exposed, infected and recovered nodes in n
+  for i := range n {
+    status := n[i].Status
+    if snapshot != nil {
+      status = snapshot[i]
+    }
+
+    if status == "R" && p.Waning > 0.0 {
+      //Recovered nodes lose their immunity with probability p.Waning
+      if r.Float64() < p.Waning {
+        n[i].Status = "S"
+      }
+    } else if status == "V" && p.Vaccine.Waning > 0.0 {
+      //And so do vaccinated nodes with probability p.Vaccine.Waning, although they stay Vaccinated
+      if r.Float64() < p.Vaccine.Waning {
+        n[i].Status = "S"
+      }
+    } else if status == "E" {
+      //Exposed nodes become infectious once their latent period is over
+      n[i].timer--
+      if n[i].timer <= 0 {
+        p.MakeInfectious(r, n[i])
+      }
+    } else if status == "I" {
+      neighbors := n[i].Connections
+
+      //Infect each susceptible neighbor with probability transmitRate, scaled by the infectiousness of n[i] and the susceptibility
+      //of the neighbor and reduced for neighbors protected by a leaky vaccine. If the neighbor is either dead or immune ("D" or "R"),
+      //they cannot be infected
+      for k := range neighbors {
+        infectChance := r.Float64()
+        protection := p.Vaccine.Susceptibility(neighbors[k])
+        if snapshot != nil && snapshot[neighbors[k].ID] != neighbors[k].Status {
+          //The neighbor was already infected, or lost its immunity, during this timestep
+          protection = 0
+        }
+        if protection > 0 && infectChance <= transmitRate * n[i].Infectiousness * neighbors[k].Susceptibility * protection {
+          p.Infect(r, neighbors[k], n[i], epoch)
+        }
+      }
+
+      //Once the infectious period is over, we update the status of the infected node to either dead "D" or immune "R" with
+      //probability of death based on the lethality of the pathogen and the vulnerability of the node
+      n[i].timer--
+      if n[i].timer <= 0 {
+        deathChance := r.Float64()
+        if deathChance <= p.LethalityFor(n[i]) {
+          n[i].Status = "D"
+        } else {
+          n[i].Status = "R"
+        }
+      }
+    }
+  }
+
+  return n
+
+}
+
+//Initially used for debugging and readability, just converts the single character status into a single word description for
+//a given Node.
+func ReadStatus(n *Node) string {
+  if n.Status == "S" {
+    return "susceptible"
+  } else if n.Status == "E" {
+    return "exposed"
+  } else if n.Status == "I" {
+    return "infected"
+  } else if n.Status == "V" {
+    return "immune"
+  } else if n.Status == "R" {
+    return "recovered"
+  } else if n.Status == "D" {
+    return "dead"
+  } else {
+    return "ERROR READING STATUS"
+  }
+}
+
+//MeyersAlpha and MeyersKappa are the exponent and cutoff of the power-law degree distribution given by Meyers et al.
+//BuildNetwork uses them with a constant C of a tenth of the population.
+const (
+  MeyersAlpha = 2.0
+  MeyersKappa = 94.2
+)
+
+//BuildNetwork creates a network of pop nodes and connects it using the parameters given by Meyers et al.
+func BuildNetwork(r *rand.Rand, pop int) Network {
+  return BuildNetworkWith(r, pop, NewMeyersGenerator(pop))
+}
+
+//BuildNetworkWith creates a network of pop nodes and connects it with the generator g
+func BuildNetworkWith(r *rand.Rand, pop int, g Generator) Network {
+  net := make(Network, pop)
+  net.InitializeNetwork(r)
+  g.Connect(r, net)
+  return net
+}
+
+//RunEpidemic keeps infecting the network with the engine e (nil for the SynchronousEngine) until it is no longer
+//infected and returns the epidemic curve, starting with the state of the network before the first timestep, along
+//with the effective reproduction number of every timestep. With waning immunity an epidemic may never be over, so
+//if maxEpochs is more than 0 it is stopped after that many timesteps (see EndemicStats). If visit is not nil it is
+//called after every timestep with the number of that timestep, starting at 1.
+func RunEpidemic(r *rand.Rand, net Network, p Pathogen, e Engine, maxEpochs int, visit func(epoch int)) TimeSeries {
+  if e == nil {
+    e = SynchronousEngine{}
+  }
+
+  //numEpochs is used to keep track of what timestep we are in.
+  numEpochs := 1
+
+  ts := TimeSeries{RecordEpoch(net, 0, nil)}
+
+  for true {
+    e.Step(r, net, p, numEpochs)
+    ts = append(ts, RecordEpoch(net, numEpochs, &ts[len(ts) - 1]))
+    if visit != nil {
+      visit(numEpochs)
+    }
+
+    if net.IsInfected() == false || (maxEpochs > 0 && numEpochs >= maxEpochs) {
+      break
+    }
+
+    numEpochs++
+  }
+
+  ts.SetReproductionNumbers(BuildTransmissionTree(net))
+  return ts
+}
+
+//CountStatuses maps every status description (see ReadStatus) to the number of nodes in the network with that
+//status. Vaccinated nodes are also counted separately: "vaccinated" is the number of nodes that got the vaccine,
+//"breakthrough" how many of them were infected anyway and "breakthrough dead" how many of those died. "ever
+//infected" counts the nodes infected at least once, which with waning immunity can be susceptible again, and "prior
+//immune" the recovered nodes that were never infected because they had the disease before the simulation started
+//(see Node.PriorImmunity).
+func CountStatuses(net Network) map[string]int {
+  m := make(map[string]int)
+  for i := range net {
+    m[ReadStatus(net[i])]++
+    if net[i].Infections > 0 {
+      m["ever infected"]++
+    } else if net[i].PriorImmunity && net[i].Status == "R" {
+      m["prior immune"]++
+    }
+    if net[i].Vaccinated {
+      m["vaccinated"]++
+      if net[i].Infections > 0 {
+        m["breakthrough"]++
+      }
+      if net[i].Status == "D" {
+        m["breakthrough dead"]++
+      }
+    }
+  }
+  return m
+}
+
+//WriteEpidemicToFile writes all the statistics of the epidemic of scenario s, which left behind the network n and
+//the curve ts, to a file called [outName].txt. m maps status descriptions to counts, see CountStatuses, and pred
+//is the prediction for the network before the epidemic, see ScenarioPrediction.
+func WriteEpidemicToFile(m map[string]int, s Scenario, n Network, ts TimeSeries, pred Prediction, outName string) error {
+  p := s.Pathogen
+  vacRate := s.VaccineRate
+
+
+  //Standard Go I/O code. Lots of Fprint statements so we print exactly what we want.
+  file, err := os.Create(outName + ".txt")
+  if err != nil {
+    return fmt.Errorf("cannot create statistics file: %w", err)
+  }
+
+  defer file.Close()
+  fmt.Fprint(file, "Out of a total population of ")
+  fmt.Fprint(file, len(n))
+  fmt.Fprint(file, "\r\n")
+  fmt.Fprint(file, p.Name + " killed ")
+  fmt.Fprint(file, m["dead"])
+  if m["dead"] == 1 {
+    fmt.Fprint(file, " person. ")
+  } else {
+    fmt.Fprint(file, " people. ")
+  }
+
+  fmt.Fprint(file, m["recovered"] - m["prior immune"])
+  if m["recovered"] - m["prior immune"] == 1 {
+    fmt.Fprint(file, " was infected, but survived. ")
+  } else {
+    fmt.Fprint(file, " were infected, but survived. ")
+  }
+  if m["prior immune"] == 1 {
+    fmt.Fprint(file, "1 had already recovered before the epidemic started and was not infected again. ")
+  } else if m["prior immune"] > 1 {
+    fmt.Fprint(file, m["prior immune"], " had already recovered before the epidemic started and were not infected again. ")
+  }
+
+  fmt.Fprint(file, m["immune"])
+  if m["immune"] == 1 {
+    fmt.Fprint(file, " was vaccinated and did not contract " + p.Name + ", and ")
+  } else {
+    fmt.Fprint(file, " were vaccinated and did not contract " + p.Name + ", and ")
+  }
+
+
+  fmt.Fprint(file, m["susceptible"])
+  if m["susceptible"] == 1 {
+    fmt.Fprint(file, " was susceptible to the disease but was not exposed. \r\n")
+  } else {
+    fmt.Fprint(file, " were susceptible to the disease but were not exposed. \r\n")
+  }
+
+
+
+  fmt.Fprint(file, vacRate)
+  fmt.Fprint(file, "% of the population was vaccinated, and the pathogen had a base reproductive ratio of ")
+  fmt.Fprint(file, p.Ro)
+  fmt.Fprint(file, " and a mortality rate of ")
+  fmt.Fprint(file, p.Lethality * 100)
+  fmt.Fprint(file, "%. Infected people were latent (exposed but not yet infectious) for ")
+  fmt.Fprint(file, p.Latent)
+  fmt.Fprint(file, " timesteps, then infectious for ")
+  fmt.Fprint(file, p.Infectious)
+  fmt.Fprint(file, " timesteps. ")
+  if p.Waning > 0.0 {
+    fmt.Fprint(file, "Recovered people lost their immunity with probability ")
+    fmt.Fprint(file, p.Waning)
+    fmt.Fprint(file, " every timestep. ")
+  }
+  for _, group := range AgeGroups {
+    if multiplier, ok := p.AgeLethality[group]; ok {
+      fmt.Fprint(file, "The mortality rate of the ", group, " age group was multiplied by ", multiplier, ". ")
+    }
+  }
+  fmt.Fprint(file, "\r\n\r\n")
+
+  //Breakthrough infections are only possible with an imperfect vaccine
+  fmt.Fprint(file, p.Vaccine.Describe(), " ")
+  fmt.Fprint(file, "Of the ", m["vaccinated"], " people vaccinated, ", m["breakthrough"], " were infected anyway (breakthrough infections) and ")
+  fmt.Fprint(file, m["breakthrough dead"], " of them died.\r\n\r\n")
+
+  fmt.Fprint(file, "The contact network was generated by the ", DescribeGenerator(s.Generator()), " model, with a mean degree of ")
+  fmt.Fprint(file, n.MeanDegree(), ". The epidemic was stepped through time with the ", EngineName(s.Engine), " engine.\r\n\r\n")
+
+  //Who is likely to catch the disease and who to pass it on, which shapes the frailty of the network below
+  susceptibility, infectiousness := s.Traits()
+  traits := NewTraitStats(n, susceptibility, infectiousness)
+  fmt.Fprint(file, "A contact between an infected and a susceptible person transmitted ", p.Name, " with a probability proportional to the infectiousness of the first ")
+  fmt.Fprint(file, "and the susceptibility of the second. Susceptibility was drawn from ", traits.Susceptibility, " (mean ", traits.MeanSusceptibility, ", sd ", traits.SDSusceptibility, ") ")
+  fmt.Fprint(file, "and infectiousness from ", traits.Infectiousness, " (mean ", traits.MeanInfectiousness, ", sd ", traits.SDInfectiousness, "). ")
+  fmt.Fprint(file, "Vulnerability multiplied the mortality rate.\r\n\r\n")
+
+
+  //Call the frailty and interference methods then print
+  frailty := NetworkFrailty(n)
+  interference := NetworkInterference(n)
+
+  fmt.Fprint(file, "Network Frailty Statistics: \r\n\r\n")
+  fmt.Fprint(file, "Frailty: ", frailty, " \t ", "Interference: ", interference, "\r\n")
+
+  //Who infected whom, generation by generation
+  tree := BuildTransmissionTree(n)
+  stats := NewTransmissionStats(tree, n)
+  fmt.Fprint(file, "\r\nTransmission Statistics: \r\n\r\n")
+  fmt.Fprint(file, "Infections per generation (starting with the patients zero): ")
+  for i, count := range stats.GenerationCounts {
+    if i > 0 {
+      fmt.Fprint(file, ", ")
+    }
+    fmt.Fprint(file, count)
+  }
+  fmt.Fprint(file, "\r\n")
+  if stats.MeanOffspring != nil {
+    fmt.Fprint(file, "Every infection caused ", *stats.MeanOffspring, " further infections on average. ")
+  }
+  fmt.Fprint(file, len(stats.Superspreaders), " infection(s) caused more than ", stats.SuperspreaderThreshold, " further infections (superspreading events)")
+  for i, sp := range stats.Superspreaders {
+    if i == 5 {
+      fmt.Fprint(file, " and ", len(stats.Superspreaders) - i, " more")
+      break
+    }
+    if i == 0 {
+      fmt.Fprint(file, ": ")
+    } else {
+      fmt.Fprint(file, ", ")
+    }
+    fmt.Fprint(file, "person ", sp.Node, " at timestep ", sp.Epoch, " infected ", sp.Offspring)
+  }
+  fmt.Fprint(file, ".\r\n")
+
+  //The reproduction numbers the outbreak actually reached, next to the Ro it was given
+  rep := NewReproductionStats(p, tree)
+  fmt.Fprint(file, "\r\nReproduction Numbers: \r\n\r\n")
+  fmt.Fprint(file, "Input Ro: ", p.Ro, " \t ", "Realized R0 (first ", EarlyGenerations, " generations): ")
+  if rep.RealizedR0 != nil {
+    fmt.Fprint(file, *rep.RealizedR0)
+  } else {
+    fmt.Fprint(file, "none")
+  }
+  fmt.Fprint(file, " \t ", "Dispersion k: ")
+  if k := tree.Dispersion(); math.IsInf(k, 1) {
+    fmt.Fprint(file, "infinite (no more spread out than a Poisson distribution)")
+  } else if math.IsNaN(k) {
+    fmt.Fprint(file, "none")
+  } else {
+    fmt.Fprint(file, k)
+  }
+  fmt.Fprint(file, "\r\n")
+
+  //What percolation theory predicts for this network, next to what happened
+  attack := 0
+  for i := range n {
+    if n[i].Infections > 0 {
+      attack++
+    }
+  }
+  fmt.Fprint(file, "\r\nPercolation Theory Predictions: \r\n\r\n")
+  fmt.Fprint(file, "Transmissibility T: ", pred.Transmissibility, " \t ", "Epidemic threshold T_c: ", pred.Threshold, "\r\n")
+  if pred.Epidemic {
+    fmt.Fprint(file, "Above the threshold, so major outbreaks are possible. ")
+  } else {
+    fmt.Fprint(file, "Below the threshold, so no major outbreak is expected. ")
+  }
+  fmt.Fprint(file, "Predicted probability of a major outbreak: ", pred.MajorProbability, "\r\n")
+  fmt.Fprint(file, "Predicted size of a major outbreak: ", pred.OutbreakSize, " \t ", "Simulated attack rate: ", float64(attack) / float64(len(n)), "\r\n")
+
+  //Recurring waves and the endemic equilibrium, for pathogens that did not burn out
+  endemic := NewEndemicStats(ts)
+  fmt.Fprint(file, "\r\nEndemic Statistics: \r\n\r\n")
+  if endemic.Persisted {
+    fmt.Fprint(file, p.Name, " was still circulating when the simulation stopped after ", ts.Duration(), " timesteps. ")
+  } else {
+    fmt.Fprint(file, p.Name, " died out after ", ts.Duration(), " timesteps. ")
+  }
+  fmt.Fprint(file, "Over the last ", endemic.Window, " timesteps, on average ", endemic.MeanPrevalence * 100, "% of the living were infected, ")
+  fmt.Fprint(file, endemic.MeanIncidence * 100, "% newly infected every timestep and ", endemic.MeanSusceptible * 100, "% susceptible.\r\n")
+  if len(endemic.WavePeaks) > 0 {
+    fmt.Fprint(file, len(endemic.WavePeaks), " wave(s) peaked at timestep(s) ")
+    for i, peak := range endemic.WavePeaks {
+      if i > 0 {
+        fmt.Fprint(file, ", ")
+      }
+      fmt.Fprint(file, peak)
+    }
+    if endemic.MeanInterval != nil {
+      fmt.Fprint(file, ", ", *endemic.MeanInterval, " timesteps apart on average")
+    }
+    fmt.Fprint(file, ".\r\n")
+  }
+
+  //The seed is recorded so that this exact epidemic can be replayed with -seed
+  fmt.Fprint(file, "\r\nRandom seed: ", s.Seed, "\r\n")
+
+  return file.Close()
+
+}
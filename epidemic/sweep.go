@@ -0,0 +1,170 @@
+package epidemic
+
+import (
+  "context"
+  "fmt"
+  "io"
+  "os"
+  "sort"
+)
+
+//SweepPoint is the ensemble of replicates run at one point of a parameter sweep, Coverage being the
+//percentage of the population vaccinated there with the vaccination strategy named Strategy
+type SweepPoint struct {
+  Ro float64
+  Strategy string
+  Coverage float64
+  Results []ReplicateResult
+}
+
+//RunSweep runs an ensemble of runs replicates of a scenario for every combination of vaccination coverage (in
+//percent), Ro and vaccination strategy. An empty ros slice keeps the Ro of the scenario's pathogen, and an empty
+//strategies slice the scenario's strategy. Every point reuses the same master seed, so the replicates at different
+//points share their networks and only the swept parameters change between them. The replicates of all the points
+//run in parallel on all the CPUs, see Runner.
+func RunSweep(s Scenario, runs int, coverages, ros []float64, strategies []Strategy) []SweepPoint {
+  //The background context is never cancelled, so every point is complete
+  points, _ := Runner{}.Sweep(context.Background(), s, runs, coverages, ros, strategies)
+  return points
+}
+
+//plannedPoint is a point of a sweep, yet to be run, along with the scenario to run there
+type plannedPoint struct {
+  SweepPoint
+  scenario Scenario
+}
+
+//sweepPoints lists the points of a sweep in the order RunSweep runs them, see RunSweep
+func sweepPoints(s Scenario, coverages, ros []float64, strategies []Strategy) []plannedPoint {
+  if len(ros) == 0 {
+    ros = []float64{s.Pathogen.Ro}
+  }
+  if len(strategies) == 0 {
+    strategies = []Strategy{s.Strategy}
+  }
+
+  points := make([]plannedPoint, 0, len(coverages) * len(ros) * len(strategies))
+  for _, ro := range ros {
+    for _, strategy := range strategies {
+      for _, coverage := range coverages {
+        point := s
+        point.Pathogen.Ro = ro
+        point.Strategy = strategy
+        point.VaccineRate = coverage
+
+        points = append(points, plannedPoint{SweepPoint{ro, StrategyName(strategy), coverage, nil}, point})
+      }
+    }
+  }
+
+  return points
+}
+
+//sweepSeries identifies the points of a sweep that only differ in their coverage
+type sweepSeries struct {
+  Ro float64
+  Strategy string
+}
+
+//HerdImmunityThreshold returns the lowest swept coverage for the given Ro and strategy at and above which none of
+//the replicates was a major outbreak. The bool is false if large outbreaks still happen at the highest coverage.
+//The points may come in any order.
+func HerdImmunityThreshold(points []SweepPoint, ro float64, strategy string, pop int, major float64) (float64, bool) {
+  threshold := 0.0
+  found := false
+
+  series := make([]SweepPoint, 0)
+  for _, point := range points {
+    if point.Ro == ro && point.Strategy == strategy {
+      series = append(series, point)
+    }
+  }
+  sort.SliceStable(series, func(i, j int) bool {
+    return series[i].Coverage < series[j].Coverage
+  })
+
+  //Going up in coverage, the last major outbreak resets the estimate
+  for _, point := range series {
+    if CountMajor(point.Results, pop, major) > 0 {
+      found = false
+    } else if found == false {
+      threshold = point.Coverage
+      found = true
+    }
+  }
+
+  return threshold, found
+}
+
+//AnalyticThreshold returns the classic herd immunity threshold 1 - 1/Ro as a percentage of the population
+func AnalyticThreshold(ro float64) float64 {
+  if ro <= 1.0 {
+    return 0.0
+  }
+  return (1.0 - 1.0 / ro) * 100.0
+}
+
+//sweptSeries returns every combination of Ro and strategy of a sweep once, in the order they were first swept
+func sweptSeries(points []SweepPoint) []sweepSeries {
+  series := make([]sweepSeries, 0)
+  seen := make(map[sweepSeries]bool)
+  for _, point := range points {
+    current := sweepSeries{point.Ro, point.Strategy}
+    if seen[current] == false {
+      seen[current] = true
+      series = append(series, current)
+    }
+  }
+  return series
+}
+
+//sweepAttack summarizes the attack rates of one point of a sweep and returns the fraction of its replicates that
+//were major outbreaks
+func sweepAttack(point SweepPoint, pop int, major float64) (Summary, float64) {
+  attack := make([]float64, len(point.Results))
+  for i := range point.Results {
+    attack[i] = point.Results[i].AttackRate(pop)
+  }
+  majorFrac := float64(CountMajor(point.Results, pop, major)) / float64(len(point.Results))
+  return Summarize(attack), majorFrac
+}
+
+//WriteSweepToFile writes the attack rate and the fraction of major outbreaks at every point of a sweep in a
+//population of size pop to [outName]_sweep.csv, along with the outbreak size and probability percolation theory
+//predicts there
+func WriteSweepToFile(points []SweepPoint, pop int, major float64, outName string) error {
+  file, err := os.Create(outName + "_sweep.csv")
+  if err != nil {
+    return fmt.Errorf("cannot create sweep file: %w", err)
+  }
+  defer file.Close()
+
+  fmt.Fprintln(file, "ro,strategy,coverage,replicates,attack_rate_mean,attack_rate_median,attack_rate_lo,attack_rate_hi,major_fraction,predicted_size,predicted_major")
+  for _, point := range points {
+    s, majorFrac := sweepAttack(point, pop, major)
+    pred := meanPrediction(point.Results)
+    fmt.Fprintf(file, "%g,%s,%g,%d,%g,%g,%g,%g,%g,%g,%g\n", point.Ro, point.Strategy, point.Coverage, len(point.Results), s.Mean, s.Median, s.Lo, s.Hi, majorFrac, pred.OutbreakSize, pred.MajorProbability)
+  }
+
+  return file.Close()
+}
+
+//WriteSweepSummary writes a table of the attack rate at every point of a sweep to w, along with the estimated
+//herd immunity threshold for every Ro and strategy next to the analytic one for random vaccination
+func WriteSweepSummary(w io.Writer, points []SweepPoint, pop int, major float64) {
+  fmt.Fprintf(w, "%8s %14s %9s %12s %12s %12s\n", "Ro", "strategy", "coverage", "attack rate", "95% lo", "95% hi")
+  for _, point := range points {
+    s, _ := sweepAttack(point, pop, major)
+    fmt.Fprintf(w, "%8g %14s %8g%% %12.4f %12.4f %12.4f\n", point.Ro, point.Strategy, point.Coverage, s.Mean, s.Lo, s.Hi)
+  }
+
+  fmt.Fprintln(w, "")
+  for _, series := range sweptSeries(points) {
+    threshold, found := HerdImmunityThreshold(points, series.Ro, series.Strategy, pop, major)
+    if found {
+      fmt.Fprintf(w, "Ro %g, %s: major outbreaks (attack rate >= %g) stop at %g%% coverage, analytic 1 - 1/Ro is %.2f%%\n", series.Ro, series.Strategy, major, threshold, AnalyticThreshold(series.Ro))
+    } else {
+      fmt.Fprintf(w, "Ro %g, %s: major outbreaks (attack rate >= %g) still occur at the highest coverage, analytic 1 - 1/Ro is %.2f%%\n", series.Ro, series.Strategy, major, AnalyticThreshold(series.Ro))
+    }
+  }
+}
@@ -0,0 +1,123 @@
+package epidemic
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "os"
+  "strconv"
+)
+
+//Epoch records the state of a network at the end of one timestep: how many nodes had each status, how many were
+//infected (NewInfections) and how many died (NewDeaths) during that timestep. Epoch 0 is the state right after the
+//patient(s) zero were infected, who are its new infections.
+type Epoch struct {
+  Epoch int `json:"epoch"`
+  Susceptible int `json:"susceptible"`
+  Vaccinated int `json:"vaccinated"`
+  Exposed int `json:"exposed"`
+  Infected int `json:"infected"`
+  Recovered int `json:"recovered"`
+  Dead int `json:"dead"`
+  NewInfections int `json:"new_infections"`
+  NewDeaths int `json:"new_deaths"`
+  //TotalInfections counts every infection up to and including this timestep, reinfections included
+  TotalInfections int `json:"total_infections"`
+  //Rt is the effective reproduction number of the infections of this timestep, see SetReproductionNumbers. It is
+  //nil until the epidemic is over, and for timesteps without new infections.
+  Rt *float64 `json:"rt"`
+}
+
+//TimeSeries is the epidemic curve of a whole run, one Epoch per timestep starting at epoch 0
+type TimeSeries []Epoch
+
+//RecordEpoch counts the statuses in the network at the given timestep. The new infections and deaths are the
+//difference with prev, the record of the timestep before, which is nil for epoch 0.
+func RecordEpoch(n Network, epoch int, prev *Epoch) Epoch {
+  e := Epoch{Epoch: epoch}
+  for i := range n {
+    switch n[i].Status {
+    case "S":
+      e.Susceptible++
+    case "V":
+      e.Vaccinated++
+    case "E":
+      e.Exposed++
+    case "I":
+      e.Infected++
+    case "R":
+      e.Recovered++
+    case "D":
+      e.Dead++
+    }
+    e.TotalInfections += n[i].Infections
+  }
+
+  e.NewInfections = e.TotalInfections
+  e.NewDeaths = e.Dead
+  if prev != nil {
+    e.NewInfections -= prev.TotalInfections
+    e.NewDeaths -= prev.Dead
+  }
+
+  return e
+}
+
+//Duration returns the number of timesteps the epidemic lasted
+func (ts TimeSeries) Duration() int {
+  return len(ts) - 1
+}
+
+//WriteCSV writes the time series as CSV, one line per timestep
+func (ts TimeSeries) WriteCSV(w io.Writer) error {
+  _, err := fmt.Fprintln(w, "epoch,susceptible,vaccinated,exposed,infected,recovered,dead,new_infections,new_deaths,total_infections,rt")
+  if err != nil {
+    return err
+  }
+
+  for _, e := range ts {
+    rt := ""
+    if e.Rt != nil {
+      rt = strconv.FormatFloat(*e.Rt, 'g', -1, 64)
+    }
+    _, err = fmt.Fprintf(w, "%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%s\n", e.Epoch, e.Susceptible, e.Vaccinated, e.Exposed, e.Infected, e.Recovered, e.Dead, e.NewInfections, e.NewDeaths, e.TotalInfections, rt)
+    if err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+//WriteJSON writes the time series as a JSON array, one object per timestep
+func (ts TimeSeries) WriteJSON(w io.Writer) error {
+  enc := json.NewEncoder(w)
+  enc.SetIndent("", "  ")
+  return enc.Encode(ts)
+}
+
+//WriteTimeSeriesToFile writes the time series to [outName]_timeseries.csv and [outName]_timeseries.json
+func WriteTimeSeriesToFile(ts TimeSeries, outName string) error {
+  csvFile, err := os.Create(outName + "_timeseries.csv")
+  if err != nil {
+    return fmt.Errorf("cannot create time series file: %w", err)
+  }
+  defer csvFile.Close()
+
+  if err := ts.WriteCSV(csvFile); err != nil {
+    return fmt.Errorf("writing %s_timeseries.csv: %w", outName, err)
+  }
+  if err := csvFile.Close(); err != nil {
+    return err
+  }
+
+  jsonFile, err := os.Create(outName + "_timeseries.json")
+  if err != nil {
+    return fmt.Errorf("cannot create time series file: %w", err)
+  }
+  defer jsonFile.Close()
+
+  if err := ts.WriteJSON(jsonFile); err != nil {
+    return fmt.Errorf("writing %s_timeseries.json: %w", outName, err)
+  }
+  return jsonFile.Close()
+}
@@ -0,0 +1,201 @@
+package epidemic
+
+import (
+  "encoding/json"
+  "fmt"
+  "math"
+  "math/rand"
+  "strconv"
+  "strings"
+)
+
+//Every contact between an infectious node i and a susceptible node j transmits the disease with a probability
+//proportional to both the infectiousness of i and the susceptibility of j (see InfectOnce). Both are traits of the
+//nodes, drawn from a Trait distribution once the network is built, and average 1 for the transmissibility of the
+//pathogen to keep its meaning.
+
+//Trait describes the distribution of a node trait such as its susceptibility or infectiousness. Dist is one of
+//TraitDists, with mean Mean and standard deviation SD:
+//
+//  constant       every node has the mean
+//  gaussian       a normal distribution, cut off at a tenth of the mean like GaussianVuln
+//  gamma          a gamma distribution, the usual choice for heterogeneous infectiousness (Lloyd-Smith et al.)
+//  lognormal      a log-normal distribution
+//  vulnerability  the node's vulnerability (see GaussianVuln), so vulnerable people are also more susceptible
+type Trait struct {
+  Dist string
+  Mean float64
+  SD float64
+}
+
+//TraitDists lists the trait distributions
+var TraitDists = []string{"constant", "gaussian", "gamma", "lognormal", "vulnerability"}
+
+//DefaultSusceptibility and DefaultInfectiousness are the traits of nodes unless a Scenario says otherwise:
+//vulnerable people are more likely to be infected, and everyone is equally infectious.
+var DefaultSusceptibility = Trait{"vulnerability", 0, 0}
+var DefaultInfectiousness = Trait{"constant", 1, 0}
+
+//ParseTrait reads a trait distribution written as "dist:mean,sd" (for example "gamma:1,0.5"), "constant:mean" or
+//"vulnerability". A plain number is a constant.
+func ParseTrait(s string) (Trait, error) {
+  dist := "constant"
+  params := s
+  if strings.Contains(s, ":") {
+    parts := strings.SplitN(s, ":", 2)
+    dist, params = parts[0], parts[1]
+  } else if s == "vulnerability" {
+    return Trait{"vulnerability", 0, 0}, nil
+  }
+
+  values := make([]float64, 0)
+  for _, field := range strings.Split(params, ",") {
+    v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+    if err != nil {
+      return Trait{}, fmt.Errorf("unable to parse %q", field)
+    } else if v < 0.0 {
+      return Trait{}, fmt.Errorf("%g must not be negative", v)
+    }
+    values = append(values, v)
+  }
+
+  switch dist {
+  case "constant":
+    if len(values) != 1 {
+      return Trait{}, fmt.Errorf("a constant takes a single value, as constant:mean")
+    }
+    return Trait{dist, values[0], 0}, nil
+  case "gaussian", "gamma", "lognormal":
+    if len(values) != 2 {
+      return Trait{}, fmt.Errorf("a %s distribution takes a mean and a standard deviation, as %s:mean,sd", dist, dist)
+    } else if values[0] == 0 {
+      return Trait{}, fmt.Errorf("the mean of a %s distribution must be greater than 0", dist)
+    }
+    return Trait{dist, values[0], values[1]}, nil
+  case "vulnerability":
+    return Trait{}, fmt.Errorf("vulnerability takes no parameters")
+  }
+  return Trait{}, fmt.Errorf("unknown distribution %q, expected %s", dist, strings.Join(TraitDists, ", "))
+}
+
+//String writes the trait in the same form ParseTrait reads
+func (t Trait) String() string {
+  mean := strconv.FormatFloat(t.Mean, 'g', -1, 64)
+  sd := strconv.FormatFloat(t.SD, 'g', -1, 64)
+  switch t.Dist {
+  case "vulnerability":
+    return t.Dist
+  case "constant":
+    return t.Dist + ":" + mean
+  }
+  return t.Dist + ":" + mean + "," + sd
+}
+
+//MarshalJSON writes the trait as a JSON string in the same form ParseTrait reads
+func (t Trait) MarshalJSON() ([]byte, error) {
+  return json.Marshal(t.String())
+}
+
+//Draw returns the trait of node. Constants and vulnerabilities take no random draws.
+func (t Trait) Draw(r *rand.Rand, node *Node) float64 {
+  return t.draw(r, node.Vulnerability)
+}
+
+//draw returns the trait of a node with the given vulnerability
+func (t Trait) draw(r *rand.Rand, vulnerability float64) float64 {
+  switch t.Dist {
+  case "vulnerability":
+    return vulnerability
+  case "gaussian":
+    return math.Max(r.NormFloat64() * t.SD + t.Mean, 0.1 * t.Mean)
+  case "gamma":
+    if t.SD == 0 {
+      return t.Mean
+    }
+    //Shape k and scale theta with k theta = mean and k theta^2 = sd^2
+    shape := t.Mean * t.Mean / (t.SD * t.SD)
+    return drawGamma(r, shape) * t.SD * t.SD / t.Mean
+  case "lognormal":
+    //The parameters of the underlying normal distribution that give this mean and standard deviation
+    sigma2 := math.Log(1 + t.SD * t.SD / (t.Mean * t.Mean))
+    mu := math.Log(t.Mean) - sigma2 / 2
+    return math.Exp(mu + math.Sqrt(sigma2) * r.NormFloat64())
+  }
+  return t.Mean
+}
+
+//drawGamma draws from a gamma distribution with the given shape and a scale of 1, with the method of Marsaglia
+//and Tsang. Shapes below 1 are drawn with shape + 1 and scaled down by U^(1/shape).
+func drawGamma(r *rand.Rand, shape float64) float64 {
+  if shape < 1 {
+    return drawGamma(r, shape + 1) * math.Pow(r.Float64(), 1 / shape)
+  }
+
+  d := shape - 1.0 / 3.0
+  c := 1 / math.Sqrt(9 * d)
+  for true {
+    x := r.NormFloat64()
+    v := 1 + c * x
+    if v <= 0 {
+      continue
+    }
+    v = v * v * v
+    u := r.Float64()
+    if math.Log(u) < 0.5 * x * x + d - d * v + d * math.Log(v) {
+      return d * v
+    }
+  }
+  return 0
+}
+
+//AssignTraits draws the susceptibility and infectiousness of every node of the network
+func (n Network) AssignTraits(r *rand.Rand, susceptibility, infectiousness Trait) {
+  for _, node := range n {
+    node.Susceptibility = susceptibility.Draw(r, node)
+    node.Infectiousness = infectiousness.Draw(r, node)
+  }
+}
+
+//EffectiveTransmissibility returns the mean probability that a contact in the network transmits the disease when
+//the transmissibility T is scaled by the infectiousness of the infector and the susceptibility of the infectee,
+//averaged over both directions of every contact. It is the transmissibility percolation theory uses (see
+//PredictOutbreak), which predicts the size of a major outbreak well. Heterogeneous infectiousness makes major
+//outbreaks less likely than predicted, though, since most patients zero are not very infectious and the few that
+//are infect many (Kenah and Robins).
+func (n Network) EffectiveTransmissibility(T float64) float64 {
+  total, count := 0.0, 0
+  for _, node := range n {
+    for _, c := range node.Connections {
+      total += math.Min(1, T * node.Infectiousness * c.Susceptibility)
+      count++
+    }
+  }
+  if count == 0 {
+    return math.Min(1, T)
+  }
+  return total / float64(count)
+}
+
+//TraitStats describes the traits the nodes of a network were given
+type TraitStats struct {
+  Susceptibility Trait `json:"susceptibility"`
+  Infectiousness Trait `json:"infectiousness"`
+  //The means and standard deviations actually drawn
+  MeanSusceptibility float64 `json:"mean_susceptibility"`
+  SDSusceptibility float64 `json:"sd_susceptibility"`
+  MeanInfectiousness float64 `json:"mean_infectiousness"`
+  SDInfectiousness float64 `json:"sd_infectiousness"`
+}
+
+//NewTraitStats describes the traits of the nodes of network n, drawn from susceptibility and infectiousness
+func NewTraitStats(n Network, susceptibility, infectiousness Trait) TraitStats {
+  sus := make([]float64, len(n))
+  inf := make([]float64, len(n))
+  for i, node := range n {
+    sus[i] = node.Susceptibility
+    inf[i] = node.Infectiousness
+  }
+  susSum := Summarize(sus)
+  infSum := Summarize(inf)
+  return TraitStats{susceptibility, infectiousness, susSum.Mean, susSum.SD, infSum.Mean, infSum.SD}
+}
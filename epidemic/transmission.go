@@ -0,0 +1,301 @@
+package epidemic
+
+import (
+  "encoding/json"
+  "fmt"
+  "io"
+  "math"
+  "os"
+  "sort"
+  "strconv"
+)
+
+//Transmission is one infection: the node with ID Infectee was infected by the node with ID Infector at timestep
+//Epoch, at time Time. Time is the timestep itself unless the EventEngine stepped the epidemic, which infects at any
+//time during the timestep. Infector is -1 for patients zero. Generation and Parent are filled in by
+//BuildTransmissionTree: patients zero are generation 0 and everyone else is one generation after their infector,
+//and Parent is the index in the tree of the infection of the infector that caused this one (-1 for patients zero).
+type Transmission struct {
+  Infector int `json:"infector"`
+  Infectee int `json:"infectee"`
+  Epoch int `json:"epoch"`
+  Time float64 `json:"time"`
+  Generation int `json:"generation"`
+  Parent int `json:"parent"`
+}
+
+//TransmissionTree is the transmission forest of an epidemic, who infected whom and when, with one tree per patient
+//zero. Events are ordered by time, and Offspring[i] is the number of infections caused by infection Events[i].
+type TransmissionTree struct {
+  Events []Transmission
+  Offspring []int
+}
+
+//BuildTransmissionTree collects the infection history of every node of the network into a transmission tree
+func BuildTransmissionTree(n Network) TransmissionTree {
+  events := make([]Transmission, 0)
+  for _, node := range n {
+    events = append(events, node.History...)
+  }
+  sort.SliceStable(events, func(i, j int) bool {
+    return events[i].Time < events[j].Time
+  })
+
+  //Index the infections of every node in order, so that the parent of an infection is the latest infection of its
+  //infector up to that time
+  infectionsOf := make(map[int][]int)
+  for i, e := range events {
+    infectionsOf[e.Infectee] = append(infectionsOf[e.Infectee], i)
+  }
+
+  tree := TransmissionTree{events, make([]int, len(events))}
+  for i := range events {
+    events[i].Parent = -1
+    if events[i].Infector < 0 {
+      continue
+    }
+    for _, j := range infectionsOf[events[i].Infector] {
+      if events[j].Time <= events[i].Time && j != i {
+        events[i].Parent = j
+      }
+    }
+    if events[i].Parent >= 0 {
+      tree.Offspring[events[i].Parent]++
+    }
+  }
+
+  //Parents may come later in the same timestep, so generations are found by walking up to the root
+  done := make([]bool, len(events))
+  for i := range events {
+    chain := make([]int, 0)
+    j := i
+    for j >= 0 && done[j] == false {
+      chain = append(chain, j)
+      j = events[j].Parent
+    }
+    generation := -1
+    if j >= 0 {
+      generation = events[j].Generation
+    }
+    for k := len(chain) - 1; k >= 0; k-- {
+      generation++
+      events[chain[k]].Generation = generation
+      done[chain[k]] = true
+    }
+  }
+
+  return tree
+}
+
+//GenerationCounts returns the number of infections in every generation, starting with the patients zero
+func (t TransmissionTree) GenerationCounts() []int {
+  counts := make([]int, 0)
+  for _, e := range t.Events {
+    for len(counts) <= e.Generation {
+      counts = append(counts, 0)
+    }
+    counts[e.Generation]++
+  }
+  return counts
+}
+
+//OffspringDistribution returns how many infections caused exactly k further infections, for every k
+func (t TransmissionTree) OffspringDistribution() []int {
+  dist := make([]int, 0)
+  for _, k := range t.Offspring {
+    for len(dist) <= k {
+      dist = append(dist, 0)
+    }
+    dist[k]++
+  }
+  return dist
+}
+
+//MeanOffspring returns the mean number of infections caused per infection, NaN if there were none
+func (t TransmissionTree) MeanOffspring() float64 {
+  total := 0
+  for _, k := range t.Offspring {
+    total += k
+  }
+  return float64(total) / float64(len(t.Offspring))
+}
+
+//SuperspreaderThreshold returns the number of infections an infection has to exceed to be a superspreading event:
+//the 99th percentile of a Poisson distribution with the mean offspring of the tree, following Lloyd-Smith et al.
+func (t TransmissionTree) SuperspreaderThreshold() int {
+  mean := t.MeanOffspring()
+  if math.IsNaN(mean) {
+    return 0
+  }
+
+  //Add up the Poisson probabilities until they reach 0.99
+  p := math.Exp(-mean)
+  cdf := p
+  k := 0
+  for cdf < 0.99 {
+    k++
+    p *= mean / float64(k)
+    cdf += p
+  }
+  return k
+}
+
+//Superspreaders returns the indices of the infections that caused more infections than SuperspreaderThreshold,
+//the largest first
+func (t TransmissionTree) Superspreaders() []int {
+  threshold := t.SuperspreaderThreshold()
+  spreaders := make([]int, 0)
+  for i, k := range t.Offspring {
+    if k > threshold {
+      spreaders = append(spreaders, i)
+    }
+  }
+  sort.SliceStable(spreaders, func(i, j int) bool {
+    return t.Offspring[spreaders[i]] > t.Offspring[spreaders[j]]
+  })
+  return spreaders
+}
+
+//infectorName returns the name of the infector of an infection, empty for patients zero
+func infectorName(n Network, e Transmission) string {
+  if e.Infector < 0 {
+    return ""
+  }
+  return NodeName(n[e.Infector])
+}
+
+//WriteCSV writes one line per infection of the tree: the names of the infector (empty for patients zero) and
+//infectee (see NodeName), the timestep, the time, the generation and the number of infections it caused
+func (t TransmissionTree) WriteCSV(w io.Writer, n Network) error {
+  if _, err := fmt.Fprintln(w, "infector,infectee,epoch,time,generation,offspring"); err != nil {
+    return err
+  }
+  for i, e := range t.Events {
+    time := strconv.FormatFloat(e.Time, 'g', -1, 64)
+    _, err := fmt.Fprintf(w, "%s,%s,%d,%s,%d,%d\n", infectorName(n, e), NodeName(n[e.Infectee]), e.Epoch, time, e.Generation, t.Offspring[i])
+    if err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+//transmissionJSON is the JSON form of one infection, with node names instead of IDs
+type transmissionJSON struct {
+  Infector *string `json:"infector"`
+  Infectee string `json:"infectee"`
+  Epoch int `json:"epoch"`
+  Time float64 `json:"time"`
+  Generation int `json:"generation"`
+  Parent int `json:"parent"`
+  Offspring int `json:"offspring"`
+}
+
+//WriteJSON writes the tree as a JSON object holding its statistics (see TransmissionStats) and the list of
+//infections, where infector is null for patients zero and parent is the index of the infection that caused it
+func (t TransmissionTree) WriteJSON(w io.Writer, n Network) error {
+  events := make([]transmissionJSON, len(t.Events))
+  for i, e := range t.Events {
+    events[i] = transmissionJSON{nil, NodeName(n[e.Infectee]), e.Epoch, e.Time, e.Generation, e.Parent, t.Offspring[i]}
+    if e.Infector >= 0 {
+      name := infectorName(n, e)
+      events[i].Infector = &name
+    }
+  }
+
+  enc := json.NewEncoder(w)
+  enc.SetIndent("", "  ")
+  return enc.Encode(struct {
+    Stats TransmissionStats `json:"statistics"`
+    Events []transmissionJSON `json:"infections"`
+  }{NewTransmissionStats(t, n), events})
+}
+
+//WriteDOT writes the tree in the Graphviz DOT format, one graph node per infection (so reinfected people appear
+//once per infection) labelled with the name of the infectee, and edges labelled with the timestep
+func (t TransmissionTree) WriteDOT(w io.Writer, n Network) error {
+  if _, err := fmt.Fprintln(w, "digraph transmission {"); err != nil {
+    return err
+  }
+  fmt.Fprintln(w, "  node [shape=circle];")
+  for i, e := range t.Events {
+    shape := ""
+    if e.Infector < 0 {
+      shape = ", shape=doublecircle"
+    }
+    fmt.Fprintf(w, "  e%d [label=%q%s];\n", i, NodeName(n[e.Infectee]), shape)
+  }
+  for i, e := range t.Events {
+    if e.Parent >= 0 {
+      fmt.Fprintf(w, "  e%d -> e%d [label=\"%d\"];\n", e.Parent, i, e.Epoch)
+    }
+  }
+  _, err := fmt.Fprintln(w, "}")
+  return err
+}
+
+//TransmissionStats summarizes a transmission tree
+type TransmissionStats struct {
+  Infections int `json:"infections"`
+  //GenerationCounts is the number of infections in every generation, starting with the patients zero
+  GenerationCounts []int `json:"generation_counts"`
+  //OffspringDistribution[k] is the number of infections that caused exactly k further infections
+  OffspringDistribution []int `json:"offspring_distribution"`
+  MeanOffspring *float64 `json:"mean_offspring"`
+  SuperspreaderThreshold int `json:"superspreader_threshold"`
+  Superspreaders []Superspreader `json:"superspreaders"`
+}
+
+//Superspreader is an infection that caused more than the superspreader threshold of further infections
+type Superspreader struct {
+  Node string `json:"node"`
+  Epoch int `json:"epoch"`
+  Offspring int `json:"offspring"`
+}
+
+//NewTransmissionStats summarizes the transmission tree of network n
+func NewTransmissionStats(t TransmissionTree, n Network) TransmissionStats {
+  stats := TransmissionStats{
+    Infections: len(t.Events),
+    GenerationCounts: t.GenerationCounts(),
+    OffspringDistribution: t.OffspringDistribution(),
+    MeanOffspring: jsonFloat(t.MeanOffspring()),
+    SuperspreaderThreshold: t.SuperspreaderThreshold(),
+    Superspreaders: make([]Superspreader, 0),
+  }
+  for _, i := range t.Superspreaders() {
+    stats.Superspreaders = append(stats.Superspreaders, Superspreader{NodeName(n[t.Events[i].Infectee]), t.Events[i].Epoch, t.Offspring[i]})
+  }
+  return stats
+}
+
+//TreeFormats lists the formats a transmission tree can be written in, see WriteTransmissionTreeToFile
+var TreeFormats = []string{"csv", "json", "dot"}
+
+//WriteTransmissionTreeToFile writes the transmission tree of network n in the given format (csv, json or dot) to
+//[outName]_transmissions.[format], returning the name of the file
+func WriteTransmissionTreeToFile(t TransmissionTree, n Network, format, outName string) (string, error) {
+  var write func(io.Writer, Network) error
+  switch format {
+  case "csv":
+    write = t.WriteCSV
+  case "json":
+    write = t.WriteJSON
+  case "dot":
+    write = t.WriteDOT
+  default:
+    return "", fmt.Errorf("unknown transmission tree format %q, expected csv, json or dot", format)
+  }
+  fileName := outName + "_transmissions." + format
+
+  file, err := os.Create(fileName)
+  if err != nil {
+    return "", fmt.Errorf("cannot create transmission tree file: %w", err)
+  }
+  defer file.Close()
+
+  if err := write(file, n); err != nil {
+    return "", fmt.Errorf("writing %s: %w", fileName, err)
+  }
+  return fileName, file.Close()
+}
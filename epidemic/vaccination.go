@@ -0,0 +1,366 @@
+package epidemic
+
+import (
+  "fmt"
+  "math"
+  "math/rand"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+//Strategy decides who gets vaccinated when there are only so many doses. Unlike Network.Vaccinate, which
+//vaccinates everyone independently with the same probability, a strategy hands out an exact number of doses to
+//susceptible nodes in the order it prefers.
+type Strategy interface {
+  //Targets returns up to doses susceptible nodes of n to vaccinate
+  Targets(r *rand.Rand, n Network, doses int) []*Node
+  Name() string
+}
+
+//RandomStrategy vaccinates susceptible nodes chosen uniformly at random
+type RandomStrategy struct{}
+
+//DegreeStrategy vaccinates the susceptible nodes with the most contacts first
+type DegreeStrategy struct{}
+
+//AcquaintanceStrategy vaccinates a random contact of a random node, again and again. Well connected nodes are
+//more likely to be someone's contact, so this finds them without knowing the network (Cohen et al.).
+type AcquaintanceStrategy struct{}
+
+//VulnerabilityStrategy vaccinates the most vulnerable susceptible nodes first
+type VulnerabilityStrategy struct{}
+
+//BetweennessStrategy vaccinates the susceptible nodes with the highest betweenness centrality first, the nodes
+//that lie on the most shortest paths between others. Exact betweenness takes time proportional to nodes times
+//edges, so it is estimated from Samples random source nodes when Samples is more than 0 and less than the
+//population (Brandes and Pich).
+type BetweennessStrategy struct {
+  Samples int
+}
+
+//Strategies lists the names ParseStrategy accepts
+var Strategies = []string{"bernoulli", "random", "degree", "acquaintance", "vulnerability", "betweenness"}
+
+//ParseStrategy reads a vaccination strategy by name, one of Strategies. Betweenness takes the number of sampled
+//sources as betweenness:samples=N (default 100, 0 for the exact betweenness). "bernoulli" is the original
+//vaccination of everyone with the same probability, returned as a nil Strategy (see Scenario).
+func ParseStrategy(spec string) (Strategy, error) {
+  name := spec
+  params := ""
+  if strings.Contains(spec, ":") {
+    parts := strings.SplitN(spec, ":", 2)
+    name, params = parts[0], parts[1]
+  }
+
+  if name == "betweenness" {
+    b := BetweennessStrategy{100}
+    if params != "" {
+      pair := strings.SplitN(params, "=", 2)
+      if len(pair) != 2 || strings.TrimSpace(pair[0]) != "samples" {
+        return nil, fmt.Errorf("unknown parameter %q for strategy betweenness, expected samples=N", params)
+      }
+      samples, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+      if err != nil || samples < 0 {
+        return nil, fmt.Errorf("betweenness samples must be an integer of at least 0")
+      }
+      b.Samples = samples
+    }
+    return b, nil
+  } else if params != "" {
+    return nil, fmt.Errorf("strategy %s takes no parameters", name)
+  }
+
+  switch name {
+  case "bernoulli":
+    return nil, nil
+  case "random":
+    return RandomStrategy{}, nil
+  case "degree":
+    return DegreeStrategy{}, nil
+  case "acquaintance":
+    return AcquaintanceStrategy{}, nil
+  case "vulnerability":
+    return VulnerabilityStrategy{}, nil
+  }
+  return nil, fmt.Errorf("unknown vaccination strategy %q, expected %s", name, strings.Join(Strategies, ", "))
+}
+
+//StrategyName returns the name of a strategy, "bernoulli" for nil
+func StrategyName(s Strategy) string {
+  if s == nil {
+    return "bernoulli"
+  }
+  return s.Name()
+}
+
+//Vaccine modes. An all-or-nothing vaccine fully protects the fraction Efficacy of the people who get it and does
+//nothing for the others, while a leaky vaccine protects everyone partially, cutting the probability of infection
+//of every contact by the fraction Efficacy.
+const (
+  AllOrNothing = "all-or-nothing"
+  Leaky = "leaky"
+)
+
+//VaccineModes lists the vaccine modes
+var VaccineModes = []string{AllOrNothing, Leaky}
+
+//Vaccine describes how well vaccination protects against a pathogen. Efficacy is between 0 and 1, Mode is one of
+//VaccineModes and Lethality multiplies the lethality of breakthrough infections, infections of vaccinated people,
+//which are often milder. Waning is the probability that a vaccinated "V" node loses its protection every timestep.
+type Vaccine struct {
+  Efficacy float64 `json:"efficacy"`
+  Mode string `json:"mode"`
+  Lethality float64 `json:"breakthrough_lethality"`
+  Waning float64 `json:"waning"`
+}
+
+//PerfectVaccine returns a vaccine that protects everyone who gets it for good
+func PerfectVaccine() Vaccine {
+  return Vaccine{1, AllOrNothing, 1, 0}
+}
+
+//ParseVaccineMode checks that mode is one of VaccineModes
+func ParseVaccineMode(mode string) (string, error) {
+  if mode != AllOrNothing && mode != Leaky {
+    return "", fmt.Errorf("unknown vaccine mode %q, expected %s", mode, strings.Join(VaccineModes, " or "))
+  }
+  return mode, nil
+}
+
+//Perfect returns true if the vaccine protects everyone who gets it
+func (v Vaccine) Perfect() bool {
+  return v.Efficacy >= 1
+}
+
+//Protect decides who the vaccine works for once the network is vaccinated. The vaccinated nodes an all-or-nothing
+//vaccine fails for, each with probability 1 - Efficacy, go back to being susceptible "S" (but stay Vaccinated).
+//Leaky vaccines leave everyone vaccinated "V" and partially protected instead, see Susceptibility.
+func (v Vaccine) Protect(r *rand.Rand, n Network) {
+  if v.Mode != AllOrNothing || v.Perfect() {
+    return
+  }
+  for _, node := range n {
+    if node.Status == "V" && r.Float64() >= v.Efficacy {
+      node.Status = "S"
+    }
+  }
+}
+
+//Susceptibility returns how much of the probability of infection a contact with an infectious node keeps for
+//node: 1 for susceptible nodes, 1 - Efficacy for nodes vaccinated with a leaky vaccine and 0 for everyone else
+func (v Vaccine) Susceptibility(node *Node) float64 {
+  if node.Status == "S" {
+    return 1
+  } else if node.Status == "V" && v.Mode == Leaky {
+    return 1 - v.Efficacy
+  }
+  return 0
+}
+
+//Describe returns a sentence describing the vaccine
+func (v Vaccine) Describe() string {
+  desc := "The vaccine protected everyone who got it."
+  if v.Perfect() == false && v.Mode == Leaky {
+    desc = fmt.Sprintf("The vaccine was leaky, cutting every vaccinated person's chance of infection by %g%%.", v.Efficacy * 100)
+  } else if v.Perfect() == false {
+    desc = fmt.Sprintf("The vaccine fully protected %g%% of the people who got it.", v.Efficacy * 100)
+  }
+  if v.Perfect() == false && v.Lethality != 1 {
+    desc += fmt.Sprintf(" The mortality rate of breakthrough infections was multiplied by %g.", v.Lethality)
+  }
+  if v.Waning > 0 {
+    desc += fmt.Sprintf(" Vaccinated people lost their protection with probability %g every timestep.", v.Waning)
+  }
+  return desc
+}
+
+//Doses returns the number of doses that vaccinates the fraction rate of a population of size pop
+func Doses(rate float64, pop int) int {
+  return int(math.Round(rate * float64(pop)))
+}
+
+//VaccinateWith vaccinates up to doses susceptible nodes of the network chosen by the strategy s, returning how
+//many were vaccinated
+func (n Network) VaccinateWith(r *rand.Rand, s Strategy, doses int) int {
+  targets := s.Targets(r, n, doses)
+  for _, node := range targets {
+    node.Status = "V"
+    node.Vaccinated = true
+  }
+  return len(targets)
+}
+
+//susceptibleNodes returns the susceptible nodes of the network in a random order, so that ties in any ranking of
+//them are broken at random
+func susceptibleNodes(r *rand.Rand, n Network) []*Node {
+  nodes := make([]*Node, 0, len(n))
+  for _, node := range n {
+    if node.Status == "S" {
+      nodes = append(nodes, node)
+    }
+  }
+  r.Shuffle(len(nodes), func(i, j int) {
+    nodes[i], nodes[j] = nodes[j], nodes[i]
+  })
+  return nodes
+}
+
+//topNodes returns the first doses of nodes after sorting them by score, highest first
+func topNodes(nodes []*Node, score func(*Node) float64, doses int) []*Node {
+  sort.SliceStable(nodes, func(i, j int) bool {
+    return score(nodes[i]) > score(nodes[j])
+  })
+  if doses < len(nodes) {
+    nodes = nodes[:doses]
+  }
+  return nodes
+}
+
+func (s RandomStrategy) Targets(r *rand.Rand, n Network, doses int) []*Node {
+  return topNodes(susceptibleNodes(r, n), func(*Node) float64 { return 0 }, doses)
+}
+
+func (s RandomStrategy) Name() string {
+  return "random"
+}
+
+func (s DegreeStrategy) Targets(r *rand.Rand, n Network, doses int) []*Node {
+  return topNodes(susceptibleNodes(r, n), func(node *Node) float64 { return float64(len(node.Connections)) }, doses)
+}
+
+func (s DegreeStrategy) Name() string {
+  return "degree"
+}
+
+func (s AcquaintanceStrategy) Targets(r *rand.Rand, n Network, doses int) []*Node {
+  available := n.CountStatus("S")
+  targets := make([]*Node, 0, doses)
+  chosen := make(map[*Node]bool)
+
+  //Give up after many fruitless tries, when the remaining susceptible nodes are nobody's contacts
+  for tries := 0; len(targets) < doses && len(targets) < available && tries < 100 * len(n); tries++ {
+    node := n[r.Intn(len(n))]
+    if len(node.Connections) == 0 {
+      continue
+    }
+    contact := node.Connections[r.Intn(len(node.Connections))]
+    if contact.Status == "S" && chosen[contact] == false {
+      chosen[contact] = true
+      targets = append(targets, contact)
+    }
+  }
+  return targets
+}
+
+func (s AcquaintanceStrategy) Name() string {
+  return "acquaintance"
+}
+
+func (s VulnerabilityStrategy) Targets(r *rand.Rand, n Network, doses int) []*Node {
+  return topNodes(susceptibleNodes(r, n), func(node *Node) float64 { return node.Vulnerability }, doses)
+}
+
+func (s VulnerabilityStrategy) Name() string {
+  return "vulnerability"
+}
+
+func (s BetweennessStrategy) Targets(r *rand.Rand, n Network, doses int) []*Node {
+  betweenness := n.Betweenness(r, s.Samples)
+  return topNodes(susceptibleNodes(r, n), func(node *Node) float64 { return betweenness[node.ID] }, doses)
+}
+
+func (s BetweennessStrategy) Name() string {
+  return "betweenness"
+}
+
+//Betweenness returns the betweenness centrality of every node, indexed by ID, with Brandes' algorithm: a breadth
+//first search from every source node counts the shortest paths through each node. If samples is more than 0 and
+//less than the population, only that many random sources are searched and the result is scaled up accordingly,
+//which ranks the nodes nearly as well in a fraction of the time.
+func (n Network) Betweenness(r *rand.Rand, samples int) []float64 {
+  sources := r.Perm(len(n))
+  if samples > 0 && samples < len(n) {
+    sources = sources[:samples]
+  }
+
+  betweenness := make([]float64, len(n))
+  sigma := make([]float64, len(n))
+  dist := make([]int, len(n))
+  delta := make([]float64, len(n))
+  preds := make([][]int, len(n))
+  for _, source := range sources {
+    for i := range n {
+      sigma[i] = 0
+      dist[i] = -1
+      delta[i] = 0
+      preds[i] = preds[i][:0]
+    }
+    sigma[source] = 1
+    dist[source] = 0
+
+    //Breadth first search, remembering the order nodes were reached in
+    order := make([]int, 0, len(n))
+    queue := []int{source}
+    for len(queue) > 0 {
+      v := queue[0]
+      queue = queue[1:]
+      order = append(order, v)
+      for _, c := range n[v].Connections {
+        w := c.ID
+        if dist[w] < 0 {
+          dist[w] = dist[v] + 1
+          queue = append(queue, w)
+        }
+        if dist[w] == dist[v] + 1 {
+          sigma[w] += sigma[v]
+          preds[w] = append(preds[w], v)
+        }
+      }
+    }
+
+    //Then add up the dependencies from the farthest nodes back
+    for i := len(order) - 1; i >= 0; i-- {
+      w := order[i]
+      for _, v := range preds[w] {
+        delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+      }
+      if w != source {
+        betweenness[w] += delta[w]
+      }
+    }
+  }
+
+  scale := float64(len(n)) / float64(len(sources))
+  for i := range betweenness {
+    betweenness[i] *= scale
+  }
+  return betweenness
+}
+
+//Unvaccinated returns a copy of the network without its vaccinated nodes and their connections, the network a
+//disease can actually spread through. The copies are numbered again in order, so that node i of the copy has ID
+//i as in any Network, and keep the Label of the original.
+func (n Network) Unvaccinated() Network {
+  copies := make(map[*Node]*Node)
+  residual := make(Network, 0, len(n))
+  for _, node := range n {
+    if node.Status != "V" {
+      c := *node
+      c.ID = len(residual)
+      c.Connections = nil
+      copies[node] = &c
+      residual = append(residual, &c)
+    }
+  }
+  for _, node := range n {
+    if c, ok := copies[node]; ok {
+      for _, contact := range node.Connections {
+        if cc, ok := copies[contact]; ok {
+          c.Connections = append(c.Connections, cc)
+        }
+      }
+    }
+  }
+  return residual
+}